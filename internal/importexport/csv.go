@@ -0,0 +1,162 @@
+// Package importexport implements `ledger import`/`export`/`reconcile`:
+// reading and writing transactions as CSV or OFX, and diffing an
+// external statement against the active account.
+package importexport
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"time"
+
+	"github.com/RichardMarks/my-little-ledger/internal/ledger"
+)
+
+// CSVMapping says which columns of a CSV file hold which fields, and
+// what date layout they're formatted with (see time.Parse).
+type CSVMapping struct {
+	DateColumn    string `json:"date"`
+	IncomeColumn  string `json:"income"`
+	ExpenseColumn string `json:"expense"`
+	MemoColumn    string `json:"memo"`
+	DateFormat    string `json:"dateFormat"`
+}
+
+// DefaultCSVMapping matches the columns ExportCSV writes.
+func DefaultCSVMapping() CSVMapping {
+	return CSVMapping{
+		DateColumn:    "Date",
+		IncomeColumn:  "Income",
+		ExpenseColumn: "Expense",
+		MemoColumn:    "Memo",
+		DateFormat:    "01/02/2006",
+	}
+}
+
+// LoadCSVMapping reads a mapping file written as the JSON form of
+// CSVMapping.
+func LoadCSVMapping(path string) (CSVMapping, error) {
+	mapping := DefaultCSVMapping()
+	fileBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return mapping, err
+	}
+	err = json.Unmarshal(fileBytes, &mapping)
+	return mapping, err
+}
+
+func columnIndex(header []string, name string) (int, error) {
+	for i, column := range header {
+		if column == name {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("csv: missing column %q", name)
+}
+
+// ImportCSV reads transactions from r using mapping to interpret its
+// columns. Each row's Balance is left at zero; callers should run the
+// result through Account's recalculation after appending.
+func ImportCSV(r io.Reader, mapping CSVMapping) ([]ledger.Transaction, error) {
+	reader := csv.NewReader(r)
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("csv: empty file")
+	}
+
+	header := rows[0]
+	dateIdx, err := columnIndex(header, mapping.DateColumn)
+	if err != nil {
+		return nil, err
+	}
+	incomeIdx, err := columnIndex(header, mapping.IncomeColumn)
+	if err != nil {
+		return nil, err
+	}
+	expenseIdx, err := columnIndex(header, mapping.ExpenseColumn)
+	if err != nil {
+		return nil, err
+	}
+	memoIdx := -1
+	if mapping.MemoColumn != "" {
+		memoIdx, _ = columnIndex(header, mapping.MemoColumn)
+	}
+
+	transactions := make([]ledger.Transaction, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		date, err := time.Parse(mapping.DateFormat, row[dateIdx])
+		if err != nil {
+			return nil, fmt.Errorf("csv: invalid date %q: %w", row[dateIdx], err)
+		}
+
+		income, err := parseMoneyField(row[incomeIdx])
+		if err != nil {
+			return nil, err
+		}
+		expense, err := parseMoneyField(row[expenseIdx])
+		if err != nil {
+			return nil, err
+		}
+
+		transaction := ledger.Transaction{
+			Timestamp: date.Unix(),
+			Income:    income,
+			Expense:   expense,
+		}
+		if memoIdx >= 0 {
+			transaction.Memo = row[memoIdx]
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, nil
+}
+
+func parseMoneyField(raw string) (ledger.Money, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("csv: invalid amount %q", raw)
+	}
+	return ledger.FToMoney(value), nil
+}
+
+// ExportCSV writes account's transactions as CSV using DefaultCSVMapping's
+// column names.
+func ExportCSV(w io.Writer, account *ledger.Account) error {
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+
+	mapping := DefaultCSVMapping()
+	if err := writer.Write([]string{mapping.DateColumn, mapping.IncomeColumn, mapping.ExpenseColumn, mapping.MemoColumn, "Balance"}); err != nil {
+		return err
+	}
+
+	for _, t := range account.Transactions {
+		row := []string{
+			time.Unix(t.Timestamp, 0).Format(mapping.DateFormat),
+			formatMoneyField(t.Income),
+			formatMoneyField(t.Expense),
+			t.Memo,
+			formatMoneyField(t.Balance),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	return writer.Error()
+}
+
+func formatMoneyField(money ledger.Money) string {
+	if money == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(ledger.MoneyToF(money), 'f', 2, 64)
+}
@@ -0,0 +1,71 @@
+package importexport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/RichardMarks/my-little-ledger/internal/ledger"
+)
+
+// ExportJSON writes account as indented JSON, the same shape data.json
+// is stored in.
+func ExportJSON(w io.Writer, account *ledger.Account) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(account)
+}
+
+const ofxHeader = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+SECURITY:NONE
+ENCODING:USASCII
+CHARSET:1252
+COMPRESSION:NONE
+OLDFILEUID:NONE
+NEWFILEUID:NONE
+
+<OFX>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<STMTRS>
+<BANKTRANLIST>
+`
+
+const ofxFooter = `</BANKTRANLIST>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`
+
+// ExportOFX writes account's transactions as an OFX 1.x SGML statement.
+func ExportOFX(w io.Writer, account *ledger.Account) error {
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return err
+	}
+
+	for i, t := range account.Transactions {
+		trnType := "CREDIT"
+		amount := ledger.MoneyToF(t.Income)
+		if t.Expense > 0 {
+			trnType = "DEBIT"
+			amount = -ledger.MoneyToF(t.Expense)
+		}
+		fitid := t.FITID
+		if fitid == "" {
+			fitid = fmt.Sprintf("%d-%d", t.Timestamp, i)
+		}
+
+		_, err := fmt.Fprintf(w, "<STMTTRN>\n<TRNTYPE>%s\n<DTPOSTED>%s\n<TRNAMT>%.2f\n<FITID>%s\n<MEMO>%s\n</STMTTRN>\n",
+			trnType, time.Unix(t.Timestamp, 0).Format("20060102"), amount, fitid, t.Memo)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, ofxFooter)
+	return err
+}
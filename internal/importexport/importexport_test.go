@@ -0,0 +1,120 @@
+package importexport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RichardMarks/my-little-ledger/internal/ledger"
+)
+
+func TestImportExportCSVRoundTrips(t *testing.T) {
+	account := ledger.CreateAccount(0)
+	account.Deposit(ledger.FToMoney(100))
+	account.Withdraw(ledger.FToMoney(25))
+	account.Transactions[0].Memo = "paycheck"
+
+	var buf strings.Builder
+	if err := ExportCSV(&buf, &account); err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := ImportCSV(strings.NewReader(buf.String()), DefaultCSVMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(imported))
+	}
+	if imported[0].Income != ledger.FToMoney(100) {
+		t.Fatalf("expected income 100, got %s", ledger.FormatMoney(imported[0].Income))
+	}
+	if imported[0].Memo != "paycheck" {
+		t.Fatalf("expected memo %q, got %q", "paycheck", imported[0].Memo)
+	}
+	if imported[1].Expense != ledger.FToMoney(25) {
+		t.Fatalf("expected expense 25, got %s", ledger.FormatMoney(imported[1].Expense))
+	}
+}
+
+const sampleOFX = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<STMTRS>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240115120000
+<TRNAMT>150.00
+<FITID>2024011501
+<MEMO>deposit
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240116120000
+<TRNAMT>-42.50
+<FITID>2024011601
+<MEMO>groceries
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`
+
+func TestImportOFXParsesStmtTrnRecords(t *testing.T) {
+	transactions, err := ImportOFX(strings.NewReader(sampleOFX))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(transactions))
+	}
+	if transactions[0].Income != ledger.FToMoney(150) || transactions[0].FITID != "2024011501" {
+		t.Fatalf("unexpected first transaction: %+v", transactions[0])
+	}
+	if transactions[1].Expense != ledger.FToMoney(42.50) || transactions[1].Memo != "groceries" {
+		t.Fatalf("unexpected second transaction: %+v", transactions[1])
+	}
+}
+
+func TestDedupeByFITIDSkipsAlreadyImportedTransactions(t *testing.T) {
+	existing := []ledger.Transaction{
+		{Timestamp: 1705320000, Income: ledger.FToMoney(150), FITID: "2024011501"},
+	}
+
+	imported, err := ImportOFX(strings.NewReader(sampleOFX))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deduped := DedupeByFITID(existing, imported)
+	if len(deduped) != 1 {
+		t.Fatalf("expected 1 new transaction, got %d: %+v", len(deduped), deduped)
+	}
+	if deduped[0].FITID != "2024011601" {
+		t.Fatalf("expected the groceries debit to survive dedup, got %+v", deduped[0])
+	}
+}
+
+func TestReconcileMatchesByFITIDAndFlagsDifferences(t *testing.T) {
+	ledgerTransactions := []ledger.Transaction{
+		{Timestamp: 1705320000, Income: ledger.FToMoney(150), FITID: "2024011501"},
+	}
+	statementTransactions, err := ImportOFX(strings.NewReader(sampleOFX))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := Reconcile(ToRecords(ledgerTransactions), ToRecords(statementTransactions))
+	if len(report.MissingFromLedger) != 1 || report.MissingFromLedger[0].FITID != "2024011601" {
+		t.Fatalf("expected the groceries debit missing from ledger, got %+v", report.MissingFromLedger)
+	}
+	if len(report.MissingFromFile) != 0 {
+		t.Fatalf("expected nothing missing from statement, got %+v", report.MissingFromFile)
+	}
+}
@@ -0,0 +1,146 @@
+package importexport
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RichardMarks/my-little-ledger/internal/ledger"
+)
+
+// ofxStmtTrn is a single <STMTTRN> record, common to both OFX 1.x SGML
+// and OFX 2.x XML.
+type ofxStmtTrn struct {
+	TrnType    string
+	TrnAmt     float64
+	DatePosted time.Time
+	FITID      string
+	Memo       string
+}
+
+var stmtTrnBlockPattern = regexp.MustCompile(`(?is)<STMTTRN>(.*?)</STMTTRN>`)
+
+// ofxField extracts a tag's value whether or not it has a closing tag:
+// OFX 1.x SGML commonly leaves tags unclosed (<TRNTYPE>CREDIT), while
+// OFX 2.x XML always closes them (<TRNTYPE>CREDIT</TRNTYPE>).
+func ofxField(block string, tag string) string {
+	pattern := regexp.MustCompile(`(?is)<` + tag + `>\s*([^<\r\n]*)`)
+	match := pattern.FindStringSubmatch(block)
+	if match == nil {
+		return ""
+	}
+	return strings.TrimSpace(match[1])
+}
+
+// parseOFXDate parses OFX's YYYYMMDD[HHMMSS][.xxx][TZ] date format,
+// using only the leading YYYYMMDD that every OFX file includes.
+func parseOFXDate(raw string) (time.Time, error) {
+	if len(raw) < 8 {
+		return time.Time{}, fmt.Errorf("ofx: invalid date %q", raw)
+	}
+	return time.Parse("20060102", raw[:8])
+}
+
+// ParseOFX reads every <STMTTRN> record from an OFX 1.x SGML or OFX 2.x
+// XML statement. The two variants share a tag-soup enough that a single
+// regexp-based field extractor handles both, which is why this doesn't
+// reach for encoding/xml: 1.x's SGML body isn't well-formed XML.
+func ParseOFX(r io.Reader) ([]ofxStmtTrn, error) {
+	fileBytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	content := string(fileBytes)
+
+	blocks := stmtTrnBlockPattern.FindAllStringSubmatch(content, -1)
+	transactions := make([]ofxStmtTrn, 0, len(blocks))
+	for _, match := range blocks {
+		block := match[1]
+
+		datePosted, err := parseOFXDate(ofxField(block, "DTPOSTED"))
+		if err != nil {
+			return nil, err
+		}
+		amount, err := strconv.ParseFloat(ofxField(block, "TRNAMT"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("ofx: invalid TRNAMT: %w", err)
+		}
+
+		transactions = append(transactions, ofxStmtTrn{
+			TrnType:    strings.ToUpper(ofxField(block, "TRNTYPE")),
+			TrnAmt:     amount,
+			DatePosted: datePosted,
+			FITID:      ofxField(block, "FITID"),
+			Memo:       ofxField(block, "MEMO"),
+		})
+	}
+	return transactions, nil
+}
+
+// DedupeByFITID filters imported down to the transactions whose FITID
+// isn't already present in existing, so re-importing a statement with
+// overlapping date ranges doesn't duplicate transactions. Imported
+// transactions without a FITID (e.g. from CSV) are always kept, since
+// there's nothing to dedup against.
+func DedupeByFITID(existing, imported []ledger.Transaction) []ledger.Transaction {
+	seen := make(map[string]bool, len(existing))
+	for _, t := range existing {
+		if t.FITID != "" {
+			seen[t.FITID] = true
+		}
+	}
+
+	deduped := make([]ledger.Transaction, 0, len(imported))
+	for _, t := range imported {
+		if t.FITID != "" {
+			if seen[t.FITID] {
+				continue
+			}
+			seen[t.FITID] = true
+		}
+		deduped = append(deduped, t)
+	}
+	return deduped
+}
+
+// ImportOFX parses an OFX statement and maps its <STMTTRN> records to
+// Transactions: TRNTYPE=CREDIT becomes Income, TRNTYPE=DEBIT becomes
+// Expense, using TRNAMT's magnitude either way. Balance is left at zero;
+// callers should recalculate running balances after appending.
+func ImportOFX(r io.Reader) ([]ledger.Transaction, error) {
+	stmtTrns, err := ParseOFX(r)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := make([]ledger.Transaction, 0, len(stmtTrns))
+	for _, trn := range stmtTrns {
+		transaction := ledger.Transaction{
+			Timestamp: trn.DatePosted.Unix(),
+			Memo:      trn.Memo,
+			FITID:     trn.FITID,
+		}
+		amount := ledger.FToMoney(trn.TrnAmt)
+		if amount < 0 {
+			amount = -amount
+		}
+		switch trn.TrnType {
+		case "CREDIT":
+			transaction.Income = amount
+		case "DEBIT":
+			transaction.Expense = amount
+		default:
+			if trn.TrnAmt >= 0 {
+				transaction.Income = amount
+			} else {
+				transaction.Expense = amount
+			}
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, nil
+}
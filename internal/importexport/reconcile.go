@@ -0,0 +1,89 @@
+package importexport
+
+import (
+	"time"
+
+	"github.com/RichardMarks/my-little-ledger/internal/ledger"
+)
+
+// Record is the common shape Reconcile compares: a signed amount (income
+// positive, expense negative) on a date, with an optional FITID for
+// exact matching when one's available.
+type Record struct {
+	FITID  string
+	Date   time.Time
+	Amount ledger.Money
+	Memo   string
+}
+
+func ledgerRecord(t ledger.Transaction) Record {
+	amount := t.Income
+	if t.Expense > 0 {
+		amount = -t.Expense
+	}
+	return Record{FITID: t.FITID, Date: time.Unix(t.Timestamp, 0), Amount: amount, Memo: t.Memo}
+}
+
+// ToRecords converts ledger or imported Transactions to Records for
+// Reconcile.
+func ToRecords(transactions []ledger.Transaction) []Record {
+	records := make([]Record, len(transactions))
+	for i, t := range transactions {
+		records[i] = ledgerRecord(t)
+	}
+	return records
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+func (r Record) matches(other Record) bool {
+	if r.FITID != "" && other.FITID != "" {
+		return r.FITID == other.FITID
+	}
+	return r.Amount == other.Amount && sameDay(r.Date, other.Date)
+}
+
+// Report is the result of comparing a ledger's records against an
+// external statement's records.
+type Report struct {
+	MissingFromLedger []Record // in the statement, not in the ledger
+	MissingFromFile   []Record // in the ledger, not in the statement
+}
+
+// Reconcile matches ledgerRecords against fileRecords by FITID when both
+// sides have one, falling back to amount+date otherwise, and reports
+// what's on only one side.
+func Reconcile(ledgerRecords, fileRecords []Record) Report {
+	matchedLedger := make([]bool, len(ledgerRecords))
+	matchedFile := make([]bool, len(fileRecords))
+
+	for i, lr := range ledgerRecords {
+		for j, fr := range fileRecords {
+			if matchedFile[j] {
+				continue
+			}
+			if lr.matches(fr) {
+				matchedLedger[i] = true
+				matchedFile[j] = true
+				break
+			}
+		}
+	}
+
+	report := Report{}
+	for i, lr := range ledgerRecords {
+		if !matchedLedger[i] {
+			report.MissingFromFile = append(report.MissingFromFile, lr)
+		}
+	}
+	for j, fr := range fileRecords {
+		if !matchedFile[j] {
+			report.MissingFromLedger = append(report.MissingFromLedger, fr)
+		}
+	}
+	return report
+}
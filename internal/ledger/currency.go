@@ -0,0 +1,126 @@
+package ledger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Currency is a currency code: an ISO-4217 code like "USD" or "JPY", or a
+// ticker like "BTC" for assets that aren't fiat.
+type Currency string
+
+// Amount is a currency-aware value. Value is an integer in the
+// currency's smallest unit, scaled by Scale decimal places, so JPY (no
+// subunit) uses Scale 0 and BTC (satoshis) uses Scale 8.
+type Amount struct {
+	Value    int64    `json:"value"`
+	Currency Currency `json:"currency"`
+	Scale    uint8    `json:"scale"`
+}
+
+// Float returns a as a decimal number, e.g. Amount{Value: 150, Scale: 2}.Float() == 1.5
+func (a Amount) Float() float64 {
+	divisor := 1.0
+	for i := uint8(0); i < a.Scale; i++ {
+		divisor *= 10
+	}
+	return float64(a.Value) / divisor
+}
+
+// String renders a with its scale's decimal places and currency code.
+func (a Amount) String() string {
+	return fmt.Sprintf("%.*f %s", a.Scale, a.Float(), a.Currency)
+}
+
+// RateProvider looks up the exchange rate to convert one unit of from
+// into to, as of date.
+type RateProvider interface {
+	Rate(from, to Currency, date time.Time) (float64, error)
+}
+
+// dateKey is the rates.json granularity: one rate per calendar day.
+func dateKey(date time.Time) string {
+	return date.UTC().Format("2006-01-02")
+}
+
+func ratePairKey(from, to Currency) string {
+	return string(from) + "/" + string(to)
+}
+
+// StaticFileRateProvider reads exchange rates from a JSON file shaped
+// like: {"2024-01-01": {"BTC/USD": 42000.0, "JPY/USD": 0.0067}}.
+type StaticFileRateProvider struct {
+	Path string
+}
+
+// Rate looks up from/to's rate on date in the configured rates.json.
+func (p StaticFileRateProvider) Rate(from, to Currency, date time.Time) (float64, error) {
+	if from == to {
+		return 1.0, nil
+	}
+
+	fileBytes, err := ioutil.ReadFile(p.Path)
+	if err != nil {
+		return 0, err
+	}
+
+	var ratesByDate map[string]map[string]float64
+	if err := json.Unmarshal(fileBytes, &ratesByDate); err != nil {
+		return 0, err
+	}
+
+	ratesForDate, ok := ratesByDate[dateKey(date)]
+	if !ok {
+		return 0, fmt.Errorf("ledger: no rates on file for %s", dateKey(date))
+	}
+
+	rate, ok := ratesForDate[ratePairKey(from, to)]
+	if !ok {
+		return 0, fmt.Errorf("ledger: no rate on file for %s on %s", ratePairKey(from, to), dateKey(date))
+	}
+	return rate, nil
+}
+
+// HTTPRateProvider fetches a rate from an HTTP endpoint of the form
+// BaseURL?from=FROM&to=TO&date=YYYY-MM-DD, expecting a JSON body
+// {"rate": 42000.0}.
+type HTTPRateProvider struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+type httpRateResponse struct {
+	Rate float64 `json:"rate"`
+}
+
+// Rate fetches from/to's rate on date from the configured endpoint.
+func (p HTTPRateProvider) Rate(from, to Currency, date time.Time) (float64, error) {
+	if from == to {
+		return 1.0, nil
+	}
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	url := fmt.Sprintf("%s?from=%s&to=%s&date=%s", p.BaseURL, from, to, dateKey(date))
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("ledger: rate provider returned status %d", resp.StatusCode)
+	}
+
+	var decoded httpRateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return 0, err
+	}
+	return decoded.Rate, nil
+}
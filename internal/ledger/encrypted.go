@@ -0,0 +1,173 @@
+package ledger
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	scryptN     = 1 << 15
+	scryptR     = 8
+	scryptP     = 1
+	scryptDKLen = 32
+	saltLen     = 32
+	nonceLen    = 12
+)
+
+// encryptedAccountFile is the on-disk envelope around an encrypted
+// account, modeled after the keystore files used by go-ethereum and
+// similar wallets.
+type encryptedAccountFile struct {
+	Version      int          `json:"version"`
+	KDF          string       `json:"kdf"`
+	KDFParams    scryptParams `json:"kdfparams"`
+	Cipher       string       `json:"cipher"`
+	CipherParams cipherParams `json:"cipherparams"`
+	Ciphertext   string       `json:"ciphertext"`
+	MAC          string       `json:"mac"`
+}
+
+type scryptParams struct {
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	Salt  string `json:"salt"`
+	DKLen int    `json:"dklen"`
+}
+
+type cipherParams struct {
+	Nonce string `json:"nonce"`
+}
+
+// ErrMACMismatch means the passphrase was wrong or the file was tampered
+// with: the derived MAC doesn't match what's stored in the envelope.
+var ErrMACMismatch = errors.New("ledger: mac mismatch, wrong passphrase or corrupted file")
+
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptDKLen)
+}
+
+func computeMAC(key []byte, ciphertext []byte) []byte {
+	half := len(key) / 2
+	mac := sha256.Sum256(append(key[half:], ciphertext...))
+	return mac[:]
+}
+
+// SaveEncrypted marshals the account and writes it to path wrapped in a
+// scrypt+AES-256-GCM envelope derived from passphrase.
+func (account *Account) SaveEncrypted(path string, passphrase string) error {
+	plaintext, err := json.Marshal(account)
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, nonceLen)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
+	mac := computeMAC(key, ciphertext)
+
+	file := encryptedAccountFile{
+		Version: 1,
+		KDF:     "scrypt",
+		KDFParams: scryptParams{
+			N: scryptN, R: scryptR, P: scryptP,
+			Salt: hex.EncodeToString(salt), DKLen: scryptDKLen,
+		},
+		Cipher:       "aes-256-gcm",
+		CipherParams: cipherParams{Nonce: hex.EncodeToString(nonce)},
+		Ciphertext:   hex.EncodeToString(ciphertext),
+		MAC:          hex.EncodeToString(mac),
+	}
+
+	fileBytes, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, fileBytes, 0600)
+}
+
+// LoadEncrypted reads an envelope written by SaveEncrypted, verifies its
+// MAC against passphrase, and unmarshals the decrypted account into
+// account.
+func (account *Account) LoadEncrypted(path string, passphrase string) error {
+	fileBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var file encryptedAccountFile
+	if err := json.Unmarshal(fileBytes, &file); err != nil {
+		return err
+	}
+
+	salt, err := hex.DecodeString(file.KDFParams.Salt)
+	if err != nil {
+		return err
+	}
+	nonce, err := hex.DecodeString(file.CipherParams.Nonce)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := hex.DecodeString(file.Ciphertext)
+	if err != nil {
+		return err
+	}
+	wantMAC, err := hex.DecodeString(file.MAC)
+	if err != nil {
+		return err
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), salt,
+		file.KDFParams.N, file.KDFParams.R, file.KDFParams.P, file.KDFParams.DKLen)
+	if err != nil {
+		return err
+	}
+
+	gotMAC := computeMAC(key, ciphertext)
+	if subtle.ConstantTimeCompare(gotMAC, wantMAC) != 1 {
+		return ErrMACMismatch
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(plaintext, account)
+}
@@ -0,0 +1,42 @@
+package ledger
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveEncryptedRoundTrip(t *testing.T) {
+	account := CreateAccount(100)
+	account.Deposit(FToMoney(50))
+
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := account.SaveEncrypted(path, "correct horse battery staple"); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := Account{}
+	if err := loaded.LoadEncrypted(path, "correct horse battery staple"); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Balance != account.Balance {
+		t.Fatalf("expected balance %s, got %s", FormatMoney(account.Balance), FormatMoney(loaded.Balance))
+	}
+	if len(loaded.Transactions) != len(account.Transactions) {
+		t.Fatalf("expected %d transactions, got %d", len(account.Transactions), len(loaded.Transactions))
+	}
+}
+
+func TestLoadEncryptedWrongPassphraseFails(t *testing.T) {
+	account := CreateAccount(100)
+
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := account.SaveEncrypted(path, "right passphrase"); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := Account{}
+	err := loaded.LoadEncrypted(path, "wrong passphrase")
+	if err != ErrMACMismatch {
+		t.Fatalf("expected ErrMACMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package ledger
+
+import "time"
+
+// DepositAmount records an incoming transaction in amount's currency,
+// converting it to the account's BaseCurrency via rates at the current
+// time. If amount is already in the base currency, no RateProvider call
+// is made.
+func (account *Account) DepositAmount(amount Amount, rates RateProvider) (Money, error) {
+	baseValue, rate, err := account.convertToBase(amount, rates)
+	if err != nil {
+		return 0, err
+	}
+	newBalance := account.makeTransaction(baseValue, 0.0)
+	account.annotateLastTransaction(amount, rate)
+	return newBalance, nil
+}
+
+// WithdrawAmount records an outgoing transaction in amount's currency,
+// converting it to the account's BaseCurrency via rates at the current
+// time. If amount is already in the base currency, no RateProvider call
+// is made.
+func (account *Account) WithdrawAmount(amount Amount, rates RateProvider) (Money, error) {
+	baseValue, rate, err := account.convertToBase(amount, rates)
+	if err != nil {
+		return 0, err
+	}
+	newBalance := account.makeTransaction(0.0, baseValue)
+	account.annotateLastTransaction(amount, rate)
+	return newBalance, nil
+}
+
+func (account *Account) convertToBase(amount Amount, rates RateProvider) (Money, float64, error) {
+	if amount.Currency == account.BaseCurrency {
+		return FToMoney(amount.Float()), 1.0, nil
+	}
+	rate, err := rates.Rate(amount.Currency, account.BaseCurrency, time.Now())
+	if err != nil {
+		return 0, 0, err
+	}
+	return FToMoney(amount.Float() * rate), rate, nil
+}
+
+func (account *Account) annotateLastTransaction(amount Amount, rate float64) {
+	if len(account.Transactions) == 0 {
+		return
+	}
+	last := &account.Transactions[len(account.Transactions)-1]
+	if amount.Currency == account.BaseCurrency {
+		return
+	}
+	last.OriginalAmount = &amount
+	last.FXRate = rate
+}
+
+// CurrencySubtotal is one line of a per-currency balance report.
+type CurrencySubtotal struct {
+	Currency Currency
+	Income   int64
+	Expense  int64
+}
+
+// CurrencySubtotals sums each transaction's OriginalAmount by currency,
+// for transactions that were recorded in a currency other than the
+// account's base currency.
+func (account *Account) CurrencySubtotals() []CurrencySubtotal {
+	subtotals := make(map[Currency]*CurrencySubtotal)
+	order := make([]Currency, 0)
+
+	for _, t := range account.Transactions {
+		if t.OriginalAmount == nil {
+			continue
+		}
+		currency := t.OriginalAmount.Currency
+		subtotal, ok := subtotals[currency]
+		if !ok {
+			subtotal = &CurrencySubtotal{Currency: currency}
+			subtotals[currency] = subtotal
+			order = append(order, currency)
+		}
+		if t.Income > 0 {
+			subtotal.Income += t.OriginalAmount.Value
+		} else {
+			subtotal.Expense += t.OriginalAmount.Value
+		}
+	}
+
+	result := make([]CurrencySubtotal, 0, len(order))
+	for _, currency := range order {
+		result = append(result, *subtotals[currency])
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package ledger
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type staticRate float64
+
+func (r staticRate) Rate(from, to Currency, date time.Time) (float64, error) {
+	return float64(r), nil
+}
+
+func TestDepositAmountConvertsToBaseCurrency(t *testing.T) {
+	account := CreateAccountWithCurrency(0, "USD")
+
+	amount := Amount{Value: 10000, Currency: "JPY", Scale: 0}
+	if _, err := account.DepositAmount(amount, staticRate(0.0067)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := FToMoney(10000 * 0.0067)
+	if account.Balance != want {
+		t.Fatalf("expected balance %s, got %s", FormatMoney(want), FormatMoney(account.Balance))
+	}
+
+	last := account.Transactions[len(account.Transactions)-1]
+	if last.OriginalAmount == nil || last.OriginalAmount.Currency != "JPY" {
+		t.Fatalf("expected original amount in JPY, got %+v", last.OriginalAmount)
+	}
+	if last.FXRate != 0.0067 {
+		t.Fatalf("expected fx rate 0.0067, got %v", last.FXRate)
+	}
+}
+
+func TestDepositAmountInBaseCurrencySkipsRateLookup(t *testing.T) {
+	account := CreateAccountWithCurrency(0, "USD")
+
+	amount := Amount{Value: 2000, Currency: "USD", Scale: 2}
+	if _, err := account.DepositAmount(amount, nil); err != nil {
+		t.Fatal(err)
+	}
+	if account.Balance != FToMoney(20) {
+		t.Fatalf("expected balance 20.00, got %s", FormatMoney(account.Balance))
+	}
+}
+
+func TestCurrencySubtotalsSumsByCurrency(t *testing.T) {
+	account := CreateAccountWithCurrency(0, "USD")
+	account.DepositAmount(Amount{Value: 100, Currency: "BTC", Scale: 8}, staticRate(42000))
+	account.DepositAmount(Amount{Value: 200, Currency: "BTC", Scale: 8}, staticRate(42000))
+	account.DepositAmount(Amount{Value: 500, Currency: "USD", Scale: 2}, staticRate(1))
+
+	subtotals := account.CurrencySubtotals()
+	if len(subtotals) != 1 {
+		t.Fatalf("expected 1 non-base currency, got %d: %+v", len(subtotals), subtotals)
+	}
+	if subtotals[0].Currency != "BTC" || subtotals[0].Income != 300 {
+		t.Fatalf("expected BTC income 300, got %+v", subtotals[0])
+	}
+}
+
+func TestStaticFileRateProviderReadsRatesJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	rates := map[string]map[string]float64{
+		"2024-01-01": {"BTC/USD": 42000.0},
+	}
+	fileBytes, _ := json.Marshal(rates)
+	if err := ioutil.WriteFile(path, fileBytes, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := StaticFileRateProvider{Path: path}
+	rate, err := provider.Rate("BTC", "USD", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate != 42000.0 {
+		t.Fatalf("expected rate 42000.0, got %v", rate)
+	}
+}
@@ -0,0 +1,150 @@
+// Package ledger holds the core domain types shared by the CLI and the
+// interactive shell: accounts, transactions, and the money type they're
+// built from.
+package ledger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// Money represents dollars and cents as an integer value
+type Money int64
+
+// Transaction represents a single transaction in an Account. Income,
+// Expense, and Balance are always expressed in the account's
+// BaseCurrency; OriginalAmount and FXRate record what was actually
+// deposited or withdrawn when that differs from the base currency.
+type Transaction struct {
+	Timestamp int64 `json:"timestamp"`
+	Income    Money `json:"income"`
+	Expense   Money `json:"expense"`
+	Balance   Money `json:"balance"`
+
+	OriginalAmount *Amount `json:"originalAmount,omitempty"`
+	FXRate         float64 `json:"fxRate,omitempty"`
+
+	// Memo is a free-text note, typically entered at the shell or carried
+	// over from an imported bank statement.
+	Memo string `json:"memo,omitempty"`
+
+	// FITID is the bank's unique transaction id from an OFX import, kept
+	// around so `ledger reconcile` and re-imports can dedup against it.
+	FITID string `json:"fitid,omitempty"`
+}
+
+// Account represents a single ledger account
+type Account struct {
+	Balance      Money         `json:"balance"`
+	StartBalance Money         `json:"startBalance"`
+	Transactions []Transaction `json:"transactions"`
+
+	// BaseCurrency is the currency Balance, StartBalance, and every
+	// Transaction's Income/Expense/Balance are expressed in. It defaults
+	// to "USD" for accounts created before multi-currency support.
+	BaseCurrency Currency `json:"baseCurrency,omitempty"`
+}
+
+// FToMoney converts a float dollar amount to Money
+func FToMoney(f float64) Money {
+	return Money(f * 100)
+}
+
+// MoneyToF converts a Money value to a float dollar amount
+func MoneyToF(money Money) float64 {
+	return float64(money) * 0.01
+}
+
+// FormatMoney renders a Money value as a dollar string
+func FormatMoney(money Money) string {
+	return fmt.Sprintf("$%10.2f", MoneyToF(money))
+}
+
+// FormatTimestamp renders a unix timestamp the way the ledger displays it
+func FormatTimestamp(timestamp int64) string {
+	// MM/DD/YYYY HH:MM:SS PM TZ
+	return time.Unix(timestamp, 0).Format("01/02/2006 03:04:05 PM MST")
+}
+
+// CreateAccount builds a new Account with the given starting balance,
+// using USD as its base currency.
+func CreateAccount(startBalance float64) Account {
+	return CreateAccountWithCurrency(startBalance, "USD")
+}
+
+// CreateAccountWithCurrency builds a new Account with the given starting
+// balance and base currency.
+func CreateAccountWithCurrency(startBalance float64, baseCurrency Currency) Account {
+	balance := FToMoney(startBalance)
+	account := Account{Balance: balance, StartBalance: balance, BaseCurrency: baseCurrency}
+	account.Transactions = make([]Transaction, 0)
+	return account
+}
+
+func (account *Account) makeTransaction(income Money, expense Money) Money {
+	newBalance := income + account.Balance - expense
+	account.Balance = newBalance
+	timestamp := time.Now().Unix()
+	transaction := Transaction{Balance: newBalance, Income: income, Expense: expense, Timestamp: timestamp}
+	account.Transactions = append(account.Transactions, transaction)
+	return newBalance
+}
+
+// RecalculateBalances replays every transaction's running balance from
+// StartBalance forward. Use after editing, deleting, or importing
+// transactions out from under the running Balance values.
+func (account *Account) RecalculateBalances() {
+	balance := account.StartBalance
+	for i := range account.Transactions {
+		balance = balance + account.Transactions[i].Income - account.Transactions[i].Expense
+		account.Transactions[i].Balance = balance
+	}
+	account.Balance = balance
+}
+
+// Deposit records an incoming transaction and returns the new balance
+func (account *Account) Deposit(amount Money) Money {
+	fmt.Printf("Depositing\t\t%s\n", FormatMoney(amount))
+	return account.makeTransaction(amount, 0.0)
+}
+
+// Withdraw records an outgoing transaction and returns the new balance
+func (account *Account) Withdraw(amount Money) Money {
+	fmt.Printf("Withdrawing\t\t%s\n", FormatMoney(amount))
+	return account.makeTransaction(0.0, amount)
+}
+
+// SaveToFile writes the account as JSON to the given path
+func (account *Account) SaveToFile(name string) error {
+	accountFileBytes, err := json.Marshal(account)
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	err = ioutil.WriteFile(name, accountFileBytes, 0644)
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	return nil
+}
+
+// ReadFromFile loads the account from a JSON file at the given path
+func (account *Account) ReadFromFile(name string) error {
+	accountFileBytes, err := ioutil.ReadFile(name)
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	err = json.Unmarshal(accountFileBytes, &account)
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	if account.BaseCurrency == "" {
+		account.BaseCurrency = "USD"
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package shell
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/chzyer/readline"
+
+	"github.com/RichardMarks/my-little-ledger/internal/workspace"
+)
+
+var commandNames = []string{
+	"deposit", "withdraw", "transfer", "ls", "edit", "delete",
+	"use", "accounts", "balance", "help", "exit",
+}
+
+// lineReader is the minimal surface Run needs from an input source: a
+// real readline.Instance when attached to a terminal, or a plain line
+// scanner when driven from a script or test.
+type lineReader interface {
+	Readline() (string, error)
+	Close() error
+}
+
+// accountCompleter completes the `use` and `transfer` account arguments
+// against the workspace's current account list.
+func accountCompleter() readline.PrefixCompleterInterface {
+	return readline.PcItemDynamic(func(line string) []string {
+		accounts, err := workspace.ListAccounts()
+		if err != nil {
+			return nil
+		}
+		return accounts
+	})
+}
+
+func newCompleter() *readline.PrefixCompleter {
+	items := make([]readline.PrefixCompleterInterface, 0, len(commandNames))
+	for _, name := range commandNames {
+		switch name {
+		case "use", "transfer":
+			items = append(items, readline.PcItem(name, accountCompleter()))
+		default:
+			items = append(items, readline.PcItem(name))
+		}
+	}
+	return readline.NewPrefixCompleter(items...)
+}
+
+// scriptReader adapts a bufio.Scanner to the lineReader interface so the
+// shell can be driven from a script (a file or, in tests, a string) without
+// going through a real terminal.
+type scriptReader struct {
+	scanner *bufio.Scanner
+}
+
+func (r *scriptReader) Readline() (string, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.scanner.Text(), nil
+}
+
+func (r *scriptReader) Close() error {
+	return nil
+}
+
+// newCompletingReader returns a readline.Instance with tab-completion when
+// in is the process's own stdin, and a plain scripted reader otherwise.
+func newCompletingReader(in io.Reader, s *Shell) (lineReader, error) {
+	if in != os.Stdin {
+		return &scriptReader{scanner: bufio.NewScanner(in)}, nil
+	}
+
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:       s.prompt(),
+		AutoComplete: newCompleter(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rl, nil
+}
+
+func (s *Shell) prompt() string {
+	return strings.TrimSpace(s.accountName) + "> "
+}
@@ -0,0 +1,527 @@
+// Package shell implements the interactive ledger REPL: `deposit`,
+// `withdraw`, `transfer`, `ls`, `edit`, `delete`, `use`, `accounts`,
+// `balance`, and `help`, run against the workspace's active account.
+package shell
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RichardMarks/my-little-ledger/internal/ledger"
+	"github.com/RichardMarks/my-little-ledger/internal/storage"
+	"github.com/RichardMarks/my-little-ledger/internal/workspace"
+)
+
+// Shell holds the state of a running REPL session: the active account
+// name and its in-memory Account, kept in sync with disk.
+type Shell struct {
+	accountName string
+	account     ledger.Account
+	out         io.Writer
+
+	// store is the workspace's configured storage backend, used for every
+	// account except the active one when it's encrypted (see passphrase):
+	// encryption is a file-level envelope the storage backends don't know
+	// about, so the active encrypted account is always read and written
+	// directly via ledger.Account.[Save|Load]Encrypted.
+	store storage.Storage
+
+	// passphrase caches the derived key's passphrase for an encrypted
+	// active account so the shell doesn't reprompt on every mutation.
+	passphrase string
+}
+
+// New builds a Shell with the workspace's currently active account loaded.
+// If the active account is encrypted, use NewWithPassphrase instead.
+func New(out io.Writer) (*Shell, error) {
+	s := &Shell{out: out}
+	if workspace.IsEncrypted(workspace.ActiveAccount()) {
+		return nil, fmt.Errorf("account %q is encrypted; run `ledger unlock` first", workspace.ActiveAccount())
+	}
+	store, err := workspace.OpenStorage()
+	if err != nil {
+		return nil, err
+	}
+	s.store = store
+	if err := s.loadActiveAccount(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewWithPassphrase builds a Shell for an encrypted active account,
+// unlocked with passphrase. The passphrase is kept in memory for the
+// life of the Shell so later mutations don't reprompt.
+func NewWithPassphrase(out io.Writer, passphrase string) (*Shell, error) {
+	s := &Shell{out: out, passphrase: passphrase}
+	store, err := workspace.OpenStorage()
+	if err != nil {
+		return nil, err
+	}
+	s.store = store
+	name := workspace.ActiveAccount()
+	account := ledger.Account{}
+	if err := account.LoadEncrypted(workspace.AccountDataPath(name), passphrase); err != nil {
+		return nil, err
+	}
+	s.accountName = name
+	s.account = account
+	return s, nil
+}
+
+func (s *Shell) loadActiveAccount() error {
+	name := workspace.ActiveAccount()
+	account, err := s.store.LoadAccount(name)
+	if err != nil {
+		return err
+	}
+	s.accountName = name
+	s.account = *account
+	return nil
+}
+
+func (s *Shell) save() error {
+	if workspace.IsEncrypted(s.accountName) {
+		return s.account.SaveEncrypted(workspace.AccountDataPath(s.accountName), s.passphrase)
+	}
+	return s.store.SaveAccount(s.accountName, &s.account)
+}
+
+func (s *Shell) printf(format string, args ...interface{}) {
+	fmt.Fprintf(s.out, format, args...)
+}
+
+// Run drives the REPL, reading commands from in until it receives EOF,
+// an "exit" command, or an unrecoverable error.
+func (s *Shell) Run(in io.Reader) error {
+	rl, err := newCompletingReader(in, s)
+	if err != nil {
+		return err
+	}
+	defer rl.Close()
+
+	for {
+		line, err := rl.Readline()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if line == "exit" || line == "quit" {
+			return nil
+		}
+		if err := s.Dispatch(line); err != nil {
+			s.printf("error: %s\n", err.Error())
+		}
+	}
+}
+
+// Dispatch parses and executes a single command line. It is split out
+// from Run so tests can drive the shell without a real readline terminal.
+func (s *Shell) Dispatch(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	command := strings.ToLower(fields[0])
+	args := fields[1:]
+
+	switch command {
+	case "deposit":
+		return s.cmdDeposit(args)
+	case "withdraw":
+		return s.cmdWithdraw(args)
+	case "transfer":
+		return s.cmdTransfer(args)
+	case "ls":
+		return s.cmdLs(args)
+	case "edit":
+		return s.cmdEdit(args)
+	case "delete":
+		return s.cmdDelete(args)
+	case "use":
+		return s.cmdUse(args)
+	case "accounts":
+		return s.cmdAccounts(args)
+	case "balance":
+		return s.cmdBalance(args)
+	case "help":
+		return s.cmdHelp(args)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+func parseAmount(raw string) (ledger.Money, error) {
+	amount, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+	return ledger.FToMoney(amount), nil
+}
+
+// currencyScales holds the decimal places currencies are recorded with,
+// for the ones that don't use the default 2 (ISO-4217's zero-decimal
+// currencies, and BTC's 8-decimal satoshi subunit).
+var currencyScales = map[ledger.Currency]uint8{
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+	"BTC": 8,
+}
+
+// currencyScale returns the decimal places a currency code is recorded
+// with, defaulting to 2 for currencies not in currencyScales.
+func currencyScale(currency ledger.Currency) uint8 {
+	if scale, ok := currencyScales[currency]; ok {
+		return scale
+	}
+	return 2
+}
+
+// parseCurrencyArg splits "<amount> [currency] [memo...]" into its parts.
+// accountCurrency is the active account's own BaseCurrency, which is
+// always recognized as a currency even when it isn't in
+// knownCurrencyCodes, so a memo can never collide with it.
+func parseCurrencyArg(args []string, accountCurrency ledger.Currency) (raw float64, currency ledger.Currency, memo string, err error) {
+	if len(args) == 0 {
+		err = fmt.Errorf("missing amount")
+		return
+	}
+	raw, err = strconv.ParseFloat(args[0], 64)
+	if err != nil {
+		err = fmt.Errorf("invalid amount %q", args[0])
+		return
+	}
+	rest := args[1:]
+	if len(rest) > 0 && isCurrencyCode(rest[0], accountCurrency) {
+		currency = ledger.Currency(rest[0])
+		rest = rest[1:]
+	}
+	memo = strings.Join(rest, " ")
+	return
+}
+
+// knownCurrencyCodes are the currency codes the shell recognizes on
+// sight, independent of any account context.
+var knownCurrencyCodes = map[ledger.Currency]bool{
+	"USD": true, "EUR": true, "GBP": true, "JPY": true, "CAD": true,
+	"AUD": true, "CHF": true, "CNY": true, "KRW": true, "VND": true,
+	"BTC": true,
+}
+
+// isCurrencyCode reports whether token is a currency the shell can
+// actually convert: one in knownCurrencyCodes, or accountCurrency, the
+// active account's own BaseCurrency. A plain memo word that happens to
+// look like a code (3-5 uppercase letters, e.g. "ATM", "ASAP") is
+// deliberately not matched, so it can't be misparsed as a currency and
+// fail an unrelated FX lookup.
+func isCurrencyCode(token string, accountCurrency ledger.Currency) bool {
+	currency := ledger.Currency(token)
+	return knownCurrencyCodes[currency] || (accountCurrency != "" && currency == accountCurrency)
+}
+
+func (s *Shell) rateProvider() ledger.RateProvider {
+	return ledger.StaticFileRateProvider{Path: path.Join(workspace.Path(), "rates.json")}
+}
+
+func (s *Shell) cmdDeposit(args []string) error {
+	raw, currency, memo, err := parseCurrencyArg(args, s.account.BaseCurrency)
+	if err != nil {
+		return fmt.Errorf("usage: deposit <amount> [currency] [memo]")
+	}
+	if currency == "" || currency == s.account.BaseCurrency {
+		s.account.Deposit(ledger.FToMoney(raw))
+	} else {
+		amount := ledger.Amount{Value: int64(raw * pow10(currencyScale(currency))), Currency: currency, Scale: currencyScale(currency)}
+		if _, err := s.account.DepositAmount(amount, s.rateProvider()); err != nil {
+			return err
+		}
+	}
+	s.setLastMemo(memo)
+	return s.save()
+}
+
+func (s *Shell) cmdWithdraw(args []string) error {
+	raw, currency, memo, err := parseCurrencyArg(args, s.account.BaseCurrency)
+	if err != nil {
+		return fmt.Errorf("usage: withdraw <amount> [currency] [memo]")
+	}
+	if currency == "" || currency == s.account.BaseCurrency {
+		s.account.Withdraw(ledger.FToMoney(raw))
+	} else {
+		amount := ledger.Amount{Value: int64(raw * pow10(currencyScale(currency))), Currency: currency, Scale: currencyScale(currency)}
+		if _, err := s.account.WithdrawAmount(amount, s.rateProvider()); err != nil {
+			return err
+		}
+	}
+	s.setLastMemo(memo)
+	return s.save()
+}
+
+func (s *Shell) setLastMemo(memo string) {
+	if memo == "" || len(s.account.Transactions) == 0 {
+		return
+	}
+	s.account.Transactions[len(s.account.Transactions)-1].Memo = memo
+}
+
+func pow10(scale uint8) float64 {
+	result := 1.0
+	for i := uint8(0); i < scale; i++ {
+		result *= 10
+	}
+	return result
+}
+
+// cmdTransfer debits amount from the active account and credits it to
+// target, rolling back the debit if the credit fails to save so a failed
+// transfer never strands money. An optional currency converts the amount
+// into each account's own BaseCurrency independently, the same as
+// cmdDeposit/cmdWithdraw; when omitted, the amount is in the active
+// account's own BaseCurrency, which is then converted into target's
+// BaseCurrency if the two differ.
+func (s *Shell) cmdTransfer(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: transfer <account> <amount> [currency]")
+	}
+	targetName := args[0]
+	raw, currency, _, err := parseCurrencyArg(args[1:], s.account.BaseCurrency)
+	if err != nil {
+		return fmt.Errorf("usage: transfer <account> <amount> [currency]")
+	}
+	if targetName == s.accountName {
+		return fmt.Errorf("cannot transfer to the active account")
+	}
+	if workspace.IsEncrypted(targetName) {
+		return fmt.Errorf("account %q is encrypted; transfer to it from its own unlocked shell instead", targetName)
+	}
+
+	target, err := s.store.LoadAccount(targetName)
+	if err != nil {
+		return fmt.Errorf("unknown account %q", targetName)
+	}
+
+	// An omitted currency means "in the active account's own currency",
+	// not "whatever currency the target happens to use" - resolve it once
+	// so both legs of the transfer convert the same amount consistently.
+	effectiveCurrency := currency
+	if effectiveCurrency == "" {
+		effectiveCurrency = s.account.BaseCurrency
+	}
+
+	before := s.account
+
+	if effectiveCurrency == s.account.BaseCurrency {
+		s.account.Withdraw(ledger.FToMoney(raw))
+	} else {
+		amount := ledger.Amount{Value: int64(raw * pow10(currencyScale(effectiveCurrency))), Currency: effectiveCurrency, Scale: currencyScale(effectiveCurrency)}
+		if _, err := s.account.WithdrawAmount(amount, s.rateProvider()); err != nil {
+			s.account = before
+			return err
+		}
+	}
+	if err := s.save(); err != nil {
+		s.account = before
+		return err
+	}
+
+	if effectiveCurrency == target.BaseCurrency {
+		target.Deposit(ledger.FToMoney(raw))
+	} else {
+		amount := ledger.Amount{Value: int64(raw * pow10(currencyScale(effectiveCurrency))), Currency: effectiveCurrency, Scale: currencyScale(effectiveCurrency)}
+		if _, depositErr := target.DepositAmount(amount, s.rateProvider()); depositErr != nil {
+			s.account = before
+			if rollbackErr := s.save(); rollbackErr != nil {
+				return fmt.Errorf("credit to %q failed (%w), and rollback of debit from %q also failed: %v", targetName, depositErr, s.accountName, rollbackErr)
+			}
+			return depositErr
+		}
+	}
+	if err := s.store.SaveAccount(targetName, target); err != nil {
+		s.account = before
+		if rollbackErr := s.save(); rollbackErr != nil {
+			return fmt.Errorf("credit to %q failed (%w), and rollback of debit from %q also failed: %v", targetName, err, s.accountName, rollbackErr)
+		}
+		return fmt.Errorf("credit to %q failed, debit from %q rolled back: %w", targetName, s.accountName, err)
+	}
+	return nil
+}
+
+func (s *Shell) cmdLs(args []string) error {
+	var from, to time.Time
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--from="):
+			t, err := time.Parse("2006-01-02", strings.TrimPrefix(arg, "--from="))
+			if err != nil {
+				return fmt.Errorf("invalid --from date: %s", err.Error())
+			}
+			from = t
+		case strings.HasPrefix(arg, "--to="):
+			t, err := time.Parse("2006-01-02", strings.TrimPrefix(arg, "--to="))
+			if err != nil {
+				return fmt.Errorf("invalid --to date: %s", err.Error())
+			}
+			to = t
+		default:
+			return fmt.Errorf("unknown flag %q", arg)
+		}
+	}
+
+	// On backends that can filter by date range at the storage layer
+	// (sqlite), use it instead of scanning every in-memory transaction, so
+	// `ls` stays fast on ledgers with 100k+ transactions.
+	if lister, ok := s.store.(storage.RangeLister); ok {
+		transactions, err := lister.ListTransactionsRange(s.accountName, from, to, 0, 0)
+		if err != nil {
+			return err
+		}
+		// ListTransactionsRange returns newest first; reverse to the same
+		// oldest-first order as s.account.Transactions, so the txid `ls`
+		// prints lines up with the index `edit`/`delete` operate on.
+		reverseTransactions(transactions)
+		s.printTransactions(transactions)
+		return nil
+	}
+
+	var transactions []ledger.Transaction
+	for _, transaction := range s.account.Transactions {
+		ts := time.Unix(transaction.Timestamp, 0)
+		if !from.IsZero() && ts.Before(from) {
+			continue
+		}
+		if !to.IsZero() && ts.After(to) {
+			continue
+		}
+		transactions = append(transactions, transaction)
+	}
+	s.printTransactions(transactions)
+	return nil
+}
+
+func reverseTransactions(transactions []ledger.Transaction) {
+	for i, j := 0, len(transactions)-1; i < j; i, j = i+1, j-1 {
+		transactions[i], transactions[j] = transactions[j], transactions[i]
+	}
+}
+
+func (s *Shell) printTransactions(transactions []ledger.Transaction) {
+	hr := strings.Repeat("-", 90)
+	for i, transaction := range transactions {
+		s.printf("%04d: IN %s OUT %s BAL %s - %s\n%s\n", i,
+			ledger.FormatMoney(transaction.Income), ledger.FormatMoney(transaction.Expense),
+			ledger.FormatMoney(transaction.Balance), ledger.FormatTimestamp(transaction.Timestamp), hr)
+	}
+}
+
+func (s *Shell) cmdEdit(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: edit <txid>")
+	}
+	txid, err := strconv.Atoi(args[0])
+	if err != nil || txid < 0 || txid >= len(s.account.Transactions) {
+		return fmt.Errorf("unknown transaction id %q", args[0])
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("usage: edit <txid> <amount>")
+	}
+	amount, err := parseAmount(args[1])
+	if err != nil {
+		return err
+	}
+	transaction := &s.account.Transactions[txid]
+	if transaction.Income > 0 {
+		transaction.Income = amount
+	} else {
+		transaction.Expense = amount
+	}
+	s.account.RecalculateBalances()
+	return s.save()
+}
+
+func (s *Shell) cmdDelete(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: delete <txid>")
+	}
+	txid, err := strconv.Atoi(args[0])
+	if err != nil || txid < 0 || txid >= len(s.account.Transactions) {
+		return fmt.Errorf("unknown transaction id %q", args[0])
+	}
+	s.account.Transactions = append(s.account.Transactions[:txid], s.account.Transactions[txid+1:]...)
+	s.account.RecalculateBalances()
+	return s.save()
+}
+
+func (s *Shell) cmdUse(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: use <account>")
+	}
+	name := args[0]
+	if workspace.IsEncrypted(name) {
+		return fmt.Errorf("account %q is encrypted; run `ledger unlock` to switch to it", name)
+	}
+	account, err := s.store.LoadAccount(name)
+	if err != nil {
+		return fmt.Errorf("unknown account %q", name)
+	}
+	s.accountName = name
+	s.account = *account
+	workspace.SelectActiveAccount(name)
+	return nil
+}
+
+func (s *Shell) cmdAccounts(args []string) error {
+	accounts, err := workspace.ListAccounts()
+	if err != nil {
+		return err
+	}
+	for _, name := range accounts {
+		marker := "  "
+		if name == s.accountName {
+			marker = "* "
+		}
+		s.printf("%s%s\n", marker, name)
+	}
+	return nil
+}
+
+func (s *Shell) cmdBalance(args []string) error {
+	s.printf("%s: %s\n", s.accountName, ledger.FormatMoney(s.account.Balance))
+
+	byCurrency := len(args) > 0 && args[0] == "--by-currency"
+	if byCurrency {
+		for _, subtotal := range s.account.CurrencySubtotals() {
+			net := subtotal.Income - subtotal.Expense
+			amount := ledger.Amount{Value: net, Currency: subtotal.Currency, Scale: currencyScale(subtotal.Currency)}
+			s.printf("  %s\n", amount.String())
+		}
+		s.printf("  base (%s) roll-up: %s\n", s.account.BaseCurrency, ledger.FormatMoney(s.account.Balance))
+	}
+	return nil
+}
+
+func (s *Shell) cmdHelp(args []string) error {
+	s.printf("Commands:\n")
+	s.printf("  deposit <amount> [currency] [memo]    deposit into the active account\n")
+	s.printf("  withdraw <amount> [currency] [memo]   withdraw from the active account\n")
+	s.printf("  transfer <account> <amount> [currency]   move funds to another account\n")
+	s.printf("  ls [--from=YYYY-MM-DD] [--to=YYYY-MM-DD]   list transactions\n")
+	s.printf("  edit <txid> <amount>           change a transaction's amount\n")
+	s.printf("  delete <txid>                  remove a transaction\n")
+	s.printf("  use <account>                  switch the active account\n")
+	s.printf("  accounts                       list every account\n")
+	s.printf("  balance [--by-currency]        show the active account's balance\n")
+	s.printf("  help                           show this message\n")
+	s.printf("  exit                           leave the shell\n")
+	return nil
+}
@@ -0,0 +1,286 @@
+package shell
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RichardMarks/my-little-ledger/internal/ledger"
+	"github.com/RichardMarks/my-little-ledger/internal/workspace"
+)
+
+// withTestWorkspace creates a throwaway .ledger/ workspace rooted at a temp
+// directory and chdirs into it for the duration of the test.
+func withTestWorkspace(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	if err := workspace.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	if err := workspace.CreateNewAccount("savings"); err != nil {
+		t.Fatal(err)
+	}
+	workspace.SelectActiveAccount("default")
+}
+
+func runScript(t *testing.T, script string) string {
+	t.Helper()
+	s, err := New(os.Stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	s.out = &out
+	if err := s.Run(strings.NewReader(script)); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestDepositAndWithdrawUpdateBalance(t *testing.T) {
+	withTestWorkspace(t)
+
+	runScript(t, "deposit 100\nwithdraw 25\nbalance\n")
+
+	account := ledger.Account{}
+	if err := account.ReadFromFile(workspace.AccountDataPath("default")); err != nil {
+		t.Fatal(err)
+	}
+	if account.Balance != ledger.FToMoney(75) {
+		t.Fatalf("expected balance 75.00, got %s", ledger.FormatMoney(account.Balance))
+	}
+	if len(account.Transactions) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(account.Transactions))
+	}
+}
+
+func TestDepositWithMemoLookingLikeACurrencyCodeIsNotMisparsed(t *testing.T) {
+	withTestWorkspace(t)
+
+	out := runScript(t, "deposit 100 ATM refund\n")
+	if strings.Contains(out, "error") {
+		t.Fatalf("expected no error, got %q", out)
+	}
+
+	account := ledger.Account{}
+	if err := account.ReadFromFile(workspace.AccountDataPath("default")); err != nil {
+		t.Fatal(err)
+	}
+	if account.Balance != ledger.FToMoney(100) {
+		t.Fatalf("expected balance 100.00, got %s", ledger.FormatMoney(account.Balance))
+	}
+	if len(account.Transactions) != 1 || account.Transactions[0].Memo != "ATM refund" {
+		t.Fatalf("expected memo %q, got %+v", "ATM refund", account.Transactions)
+	}
+}
+
+func TestTransferMovesFundsBetweenAccounts(t *testing.T) {
+	withTestWorkspace(t)
+
+	runScript(t, "deposit 100\ntransfer savings 40\n")
+
+	source := ledger.Account{}
+	source.ReadFromFile(workspace.AccountDataPath("default"))
+	if source.Balance != ledger.FToMoney(60) {
+		t.Fatalf("expected source balance 60.00, got %s", ledger.FormatMoney(source.Balance))
+	}
+
+	target := ledger.Account{}
+	target.ReadFromFile(workspace.AccountDataPath("savings"))
+	if target.Balance != ledger.FToMoney(40) {
+		t.Fatalf("expected target balance 40.00, got %s", ledger.FormatMoney(target.Balance))
+	}
+}
+
+func TestUseSwitchesActiveAccount(t *testing.T) {
+	withTestWorkspace(t)
+
+	runScript(t, "use savings\ndeposit 10\n")
+
+	if got := workspace.ActiveAccount(); got != "savings" {
+		t.Fatalf("expected active account savings, got %s", got)
+	}
+	savings := ledger.Account{}
+	savings.ReadFromFile(workspace.AccountDataPath("savings"))
+	if savings.Balance != ledger.FToMoney(10) {
+		t.Fatalf("expected savings balance 10.00, got %s", ledger.FormatMoney(savings.Balance))
+	}
+}
+
+func TestDeleteRecalculatesRunningBalances(t *testing.T) {
+	withTestWorkspace(t)
+
+	runScript(t, "deposit 100\ndeposit 50\ndelete 0\n")
+
+	account := ledger.Account{}
+	account.ReadFromFile(workspace.AccountDataPath("default"))
+	if account.Balance != ledger.FToMoney(50) {
+		t.Fatalf("expected balance 50.00 after delete, got %s", ledger.FormatMoney(account.Balance))
+	}
+	if len(account.Transactions) != 1 {
+		t.Fatalf("expected 1 transaction after delete, got %d", len(account.Transactions))
+	}
+}
+
+func TestUnknownCommandReportsError(t *testing.T) {
+	withTestWorkspace(t)
+
+	out := runScript(t, "frobnicate\n")
+	if !strings.Contains(out, "unknown command") {
+		t.Fatalf("expected unknown command error, got %q", out)
+	}
+}
+
+func TestDepositForeignCurrencyConvertsToBase(t *testing.T) {
+	withTestWorkspace(t)
+
+	rates := map[string]map[string]float64{}
+	today := time.Now().UTC().Format("2006-01-02")
+	rates[today] = map[string]float64{"JPY/USD": 0.0067}
+	rateBytes, _ := json.Marshal(rates)
+	if err := ioutil.WriteFile(filepath.Join(workspace.Path(), "rates.json"), rateBytes, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	runScript(t, "deposit 10000 JPY\n")
+
+	account := ledger.Account{}
+	account.ReadFromFile(workspace.AccountDataPath("default"))
+	want := ledger.FToMoney(10000 * 0.0067)
+	if account.Balance != want {
+		t.Fatalf("expected balance %s, got %s", ledger.FormatMoney(want), ledger.FormatMoney(account.Balance))
+	}
+}
+
+func TestTransferForeignCurrencyConvertsToEachAccountsBase(t *testing.T) {
+	withTestWorkspace(t)
+
+	rates := map[string]map[string]float64{}
+	today := time.Now().UTC().Format("2006-01-02")
+	rates[today] = map[string]float64{"JPY/USD": 0.0067}
+	rateBytes, _ := json.Marshal(rates)
+	if err := ioutil.WriteFile(filepath.Join(workspace.Path(), "rates.json"), rateBytes, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	runScript(t, "deposit 100\ntransfer savings 10000 JPY\n")
+
+	want := ledger.FToMoney(10000 * 0.0067)
+
+	source := ledger.Account{}
+	source.ReadFromFile(workspace.AccountDataPath("default"))
+	if source.Balance != ledger.FToMoney(100)-want {
+		t.Fatalf("expected source balance %s, got %s", ledger.FormatMoney(ledger.FToMoney(100)-want), ledger.FormatMoney(source.Balance))
+	}
+
+	target := ledger.Account{}
+	target.ReadFromFile(workspace.AccountDataPath("savings"))
+	if target.Balance != want {
+		t.Fatalf("expected target balance %s, got %s", ledger.FormatMoney(want), ledger.FormatMoney(target.Balance))
+	}
+}
+
+func TestTransferImplicitCurrencyConvertsToTargetsBase(t *testing.T) {
+	withTestWorkspace(t)
+
+	savings := ledger.CreateAccountWithCurrency(0, "JPY")
+	if err := savings.SaveToFile(workspace.AccountDataPath("savings")); err != nil {
+		t.Fatal(err)
+	}
+
+	rates := map[string]map[string]float64{}
+	today := time.Now().UTC().Format("2006-01-02")
+	rates[today] = map[string]float64{"USD/JPY": 150.0}
+	rateBytes, _ := json.Marshal(rates)
+	if err := ioutil.WriteFile(filepath.Join(workspace.Path(), "rates.json"), rateBytes, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// No [currency] argument: the 50 is in the active (default/USD)
+	// account's own currency and must still convert into savings's JPY
+	// base, not pass through as a literal 50.
+	runScript(t, "deposit 100\ntransfer savings 50\n")
+
+	source := ledger.Account{}
+	source.ReadFromFile(workspace.AccountDataPath("default"))
+	if source.Balance != ledger.FToMoney(50) {
+		t.Fatalf("expected source balance 50.00, got %s", ledger.FormatMoney(source.Balance))
+	}
+
+	want := ledger.FToMoney(50 * 150.0)
+	target := ledger.Account{}
+	target.ReadFromFile(workspace.AccountDataPath("savings"))
+	if target.Balance != want {
+		t.Fatalf("expected target balance %s converted via the USD/JPY rate, got %s", ledger.FormatMoney(want), ledger.FormatMoney(target.Balance))
+	}
+}
+
+func TestBalanceByCurrencyFormatsUsingCurrencysScale(t *testing.T) {
+	withTestWorkspace(t)
+
+	rates := map[string]map[string]float64{}
+	today := time.Now().UTC().Format("2006-01-02")
+	rates[today] = map[string]float64{"BTC/USD": 50000.0}
+	rateBytes, _ := json.Marshal(rates)
+	if err := ioutil.WriteFile(filepath.Join(workspace.Path(), "rates.json"), rateBytes, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := runScript(t, "deposit 0.001 BTC\nbalance --by-currency\n")
+	if !strings.Contains(out, "0.00100000 BTC") {
+		t.Fatalf("expected balance --by-currency to print 0.00100000 BTC, got %q", out)
+	}
+}
+
+func TestLsOrdersTransactionsSameAsEditDeleteUnderSQLite(t *testing.T) {
+	withTestWorkspace(t)
+	workspace.SetStorageBackend("sqlite")
+	if err := workspace.CreateNewAccount("default"); err != nil {
+		t.Fatal(err)
+	}
+	workspace.SelectActiveAccount("default")
+
+	out := runScript(t, "deposit 10\ndeposit 20\nls\n")
+	firstLine := strings.SplitN(out, "\n", 2)[0]
+	if !strings.HasPrefix(firstLine, "0000") || !strings.Contains(firstLine, "10.00") {
+		t.Fatalf("expected txid 0000 to be the $10 deposit, got %q", firstLine)
+	}
+
+	runScript(t, "delete 0\n")
+
+	store, err := workspace.OpenStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := store.LoadAccount("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Balance != ledger.FToMoney(20) {
+		t.Fatalf("expected deleting txid 0 to remove the $10 deposit and leave balance 20.00, got %s", ledger.FormatMoney(account.Balance))
+	}
+}
+
+func TestAccountDataPathIsUnderWorkspace(t *testing.T) {
+	withTestWorkspace(t)
+
+	got := workspace.AccountDataPath("default")
+	want := filepath.Join(workspace.Path(), "default", "data.json")
+	if got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
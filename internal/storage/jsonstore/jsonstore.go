@@ -0,0 +1,101 @@
+// Package jsonstore is the original ledger persistence backend: one
+// data.json per account, under a root directory, plus a config.json for
+// workspace-level settings.
+package jsonstore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/RichardMarks/my-little-ledger/internal/ledger"
+	"github.com/RichardMarks/my-little-ledger/internal/storage"
+)
+
+// Store implements storage.Storage by reading and writing JSON files
+// under root.
+type Store struct {
+	root string
+}
+
+// New builds a Store rooted at the given workspace directory.
+func New(root string) *Store {
+	return &Store{root: root}
+}
+
+func (s *Store) accountDir(name string) string {
+	return path.Join(s.root, name)
+}
+
+func (s *Store) accountPath(name string) string {
+	return path.Join(s.accountDir(name), "data.json")
+}
+
+func (s *Store) configPath() string {
+	return path.Join(s.root, "config.json")
+}
+
+// LoadAccount reads name's data.json.
+func (s *Store) LoadAccount(name string) (*ledger.Account, error) {
+	account := &ledger.Account{}
+	if err := account.ReadFromFile(s.accountPath(name)); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// SaveAccount writes account to name's data.json, creating its directory
+// if this is the first time name has been saved.
+func (s *Store) SaveAccount(name string, account *ledger.Account) error {
+	if err := os.MkdirAll(s.accountDir(name), os.ModePerm); err != nil {
+		return err
+	}
+	return account.SaveToFile(s.accountPath(name))
+}
+
+// ListAccounts returns the name of every account directory under root.
+func (s *Store) ListAccounts() ([]string, error) {
+	entries, err := ioutil.ReadDir(s.root)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			accounts = append(accounts, entry.Name())
+		}
+	}
+	return accounts, nil
+}
+
+// AppendTransaction loads name, appends transaction, and saves it back.
+func (s *Store) AppendTransaction(name string, transaction ledger.Transaction) error {
+	account, err := s.LoadAccount(name)
+	if err != nil {
+		return err
+	}
+	account.Transactions = append(account.Transactions, transaction)
+	account.Balance = transaction.Balance
+	return s.SaveAccount(name, account)
+}
+
+// LoadConfig reads root/config.json.
+func (s *Store) LoadConfig() (storage.Config, error) {
+	config := storage.Config{}
+	configBytes, err := ioutil.ReadFile(s.configPath())
+	if err != nil {
+		return config, err
+	}
+	err = json.Unmarshal(configBytes, &config)
+	return config, err
+}
+
+// SaveConfig writes config to root/config.json.
+func (s *Store) SaveConfig(config storage.Config) error {
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.configPath(), configBytes, 0644)
+}
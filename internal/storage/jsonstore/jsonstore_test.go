@@ -0,0 +1,62 @@
+package jsonstore
+
+import (
+	"testing"
+
+	"github.com/RichardMarks/my-little-ledger/internal/ledger"
+)
+
+func TestSaveAndLoadAccountRoundTrip(t *testing.T) {
+	store := New(t.TempDir())
+
+	account := ledger.CreateAccount(0)
+	account.Deposit(ledger.FToMoney(20))
+
+	if err := store.SaveAccount("checking", &account); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := store.LoadAccount("checking")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Balance != account.Balance {
+		t.Fatalf("expected balance %s, got %s", ledger.FormatMoney(account.Balance), ledger.FormatMoney(loaded.Balance))
+	}
+}
+
+func TestListAccountsReturnsEveryDirectory(t *testing.T) {
+	store := New(t.TempDir())
+
+	account := ledger.CreateAccount(0)
+	store.SaveAccount("checking", &account)
+	store.SaveAccount("savings", &account)
+
+	accounts, err := store.ListAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 2 {
+		t.Fatalf("expected 2 accounts, got %d: %v", len(accounts), accounts)
+	}
+}
+
+func TestAppendTransactionUpdatesBalance(t *testing.T) {
+	store := New(t.TempDir())
+
+	account := ledger.CreateAccount(0)
+	store.SaveAccount("checking", &account)
+
+	store.AppendTransaction("checking", ledger.Transaction{Income: ledger.FToMoney(30), Balance: ledger.FToMoney(30)})
+
+	loaded, err := store.LoadAccount("checking")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Balance != ledger.FToMoney(30) {
+		t.Fatalf("expected balance 30.00, got %s", ledger.FormatMoney(loaded.Balance))
+	}
+	if len(loaded.Transactions) != 1 {
+		t.Fatalf("expected 1 transaction, got %d", len(loaded.Transactions))
+	}
+}
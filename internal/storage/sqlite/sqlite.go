@@ -0,0 +1,323 @@
+// Package sqlite stores accounts and transactions in normalized SQLite
+// tables, so date-range and paginated `ls` queries stay fast on ledgers
+// with 100k+ transactions.
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/RichardMarks/my-little-ledger/internal/ledger"
+	"github.com/RichardMarks/my-little-ledger/internal/storage"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS accounts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE,
+	balance_cents INTEGER NOT NULL,
+	start_balance_cents INTEGER NOT NULL,
+	base_currency TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS transactions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	account_id INTEGER NOT NULL REFERENCES accounts(id),
+	timestamp INTEGER NOT NULL,
+	income_cents INTEGER NOT NULL,
+	expense_cents INTEGER NOT NULL,
+	balance_cents INTEGER NOT NULL,
+	memo TEXT NOT NULL DEFAULT '',
+	fitid TEXT NOT NULL DEFAULT '',
+	original_amount_value INTEGER,
+	original_amount_currency TEXT,
+	original_amount_scale INTEGER,
+	fx_rate REAL NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_transactions_account_timestamp ON transactions(account_id, timestamp);
+CREATE TABLE IF NOT EXISTS workspace_config (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	active_account TEXT NOT NULL DEFAULT '',
+	encrypted_accounts TEXT NOT NULL DEFAULT '',
+	storage TEXT NOT NULL DEFAULT 'sqlite'
+);
+`
+
+// Store implements storage.Storage against a SQLite database.
+type Store struct {
+	db *sql.DB
+}
+
+// New opens (creating if necessary) the SQLite database at path and
+// ensures its schema exists.
+func New(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close releases the underlying database handle.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) accountID(name string) (int64, error) {
+	var id int64
+	err := s.db.QueryRow(`SELECT id FROM accounts WHERE name = ?`, name).Scan(&id)
+	return id, err
+}
+
+// AccountBalance returns name's current and starting balance without
+// loading its transactions.
+func (s *Store) AccountBalance(name string) (balance, startBalance ledger.Money, err error) {
+	var b, sb int64
+	err = s.db.QueryRow(`SELECT balance_cents, start_balance_cents FROM accounts WHERE name = ?`, name).Scan(&b, &sb)
+	return ledger.Money(b), ledger.Money(sb), err
+}
+
+func scanTransaction(scan func(...interface{}) error) (ledger.Transaction, error) {
+	var t ledger.Transaction
+	var memo, fitid string
+	var originalValue sql.NullInt64
+	var originalCurrency sql.NullString
+	var originalScale sql.NullInt64
+	var fxRate float64
+	if err := scan(&t.Timestamp, &t.Income, &t.Expense, &t.Balance, &memo, &fitid, &originalValue, &originalCurrency, &originalScale, &fxRate); err != nil {
+		return t, err
+	}
+	t.Memo = memo
+	t.FITID = fitid
+	t.FXRate = fxRate
+	if originalValue.Valid && originalCurrency.Valid {
+		t.OriginalAmount = &ledger.Amount{
+			Value:    originalValue.Int64,
+			Currency: ledger.Currency(originalCurrency.String),
+			Scale:    uint8(originalScale.Int64),
+		}
+	}
+	return t, nil
+}
+
+const transactionColumns = `timestamp, income_cents, expense_cents, balance_cents, memo, fitid, original_amount_value, original_amount_currency, original_amount_scale, fx_rate`
+
+// LoadAccount rebuilds an *ledger.Account from its normalized rows.
+func (s *Store) LoadAccount(name string) (*ledger.Account, error) {
+	var balance, startBalance int64
+	var baseCurrency string
+	var accountID int64
+	err := s.db.QueryRow(`SELECT id, balance_cents, start_balance_cents, base_currency FROM accounts WHERE name = ?`, name).
+		Scan(&accountID, &balance, &startBalance, &baseCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("account %q: %w", name, err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT `+transactionColumns+`
+		FROM transactions
+		WHERE account_id = ?
+		ORDER BY timestamp ASC`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	account := &ledger.Account{
+		Balance:      ledger.Money(balance),
+		StartBalance: ledger.Money(startBalance),
+		BaseCurrency: ledger.Currency(baseCurrency),
+		Transactions: make([]ledger.Transaction, 0),
+	}
+	for rows.Next() {
+		t, err := scanTransaction(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		account.Transactions = append(account.Transactions, t)
+	}
+	return account, rows.Err()
+}
+
+// SaveAccount upserts the account row and replaces its transaction rows
+// wholesale. This mirrors the JSON backend's all-at-once semantics; see
+// AppendTransaction for the incremental path large ledgers should prefer.
+func (s *Store) SaveAccount(name string, account *ledger.Account) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO accounts (name, balance_cents, start_balance_cents, base_currency) VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET balance_cents = excluded.balance_cents, base_currency = excluded.base_currency`,
+		name, int64(account.Balance), int64(account.StartBalance), string(account.BaseCurrency))
+	if err != nil {
+		return err
+	}
+
+	var accountID int64
+	if err := tx.QueryRow(`SELECT id FROM accounts WHERE name = ?`, name).Scan(&accountID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM transactions WHERE account_id = ?`, accountID); err != nil {
+		return err
+	}
+	for _, t := range account.Transactions {
+		if err := insertTransaction(tx, accountID, t); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func insertTransaction(tx *sql.Tx, accountID int64, t ledger.Transaction) error {
+	var originalValue, originalScale interface{}
+	var originalCurrency interface{}
+	if t.OriginalAmount != nil {
+		originalValue = t.OriginalAmount.Value
+		originalCurrency = string(t.OriginalAmount.Currency)
+		originalScale = int64(t.OriginalAmount.Scale)
+	}
+	_, err := tx.Exec(`
+		INSERT INTO transactions (account_id, `+transactionColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		accountID, t.Timestamp, int64(t.Income), int64(t.Expense), int64(t.Balance),
+		t.Memo, t.FITID, originalValue, originalCurrency, originalScale, t.FXRate)
+	return err
+}
+
+// ListAccounts returns every account name in the database.
+func (s *Store) ListAccounts() ([]string, error) {
+	rows, err := s.db.Query(`SELECT name FROM accounts ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, name)
+	}
+	return accounts, rows.Err()
+}
+
+// AppendTransaction inserts a single transaction row and updates the
+// account's running balance, without rewriting the whole history.
+func (s *Store) AppendTransaction(name string, transaction ledger.Transaction) error {
+	accountID, err := s.accountID(name)
+	if err != nil {
+		return fmt.Errorf("account %q: %w", name, err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertTransaction(tx, accountID, transaction); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE accounts SET balance_cents = ? WHERE id = ?`, int64(transaction.Balance), accountID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListTransactionsRange returns name's transactions between from and to
+// (whichever are non-zero), newest first, using the (account_id,
+// timestamp) index — this is the fast path `ls --from --to` should use
+// once a ledger is backed by SQLite instead of the JSON store's full scan.
+// limit <= 0 means no limit.
+func (s *Store) ListTransactionsRange(name string, from, to time.Time, limit, offset int) ([]ledger.Transaction, error) {
+	accountID, err := s.accountID(name)
+	if err != nil {
+		return nil, fmt.Errorf("account %q: %w", name, err)
+	}
+
+	var conditions []string
+	args := []interface{}{accountID}
+	conditions = append(conditions, "account_id = ?")
+	if !from.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, from.Unix())
+	}
+	if !to.IsZero() {
+		conditions = append(conditions, "timestamp <= ?")
+		args = append(args, to.Unix())
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM transactions
+		WHERE %s
+		ORDER BY timestamp DESC`, transactionColumns, strings.Join(conditions, " AND "))
+	if limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, offset)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := make([]ledger.Transaction, 0)
+	for rows.Next() {
+		t, err := scanTransaction(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
+// LoadConfig reads the single workspace_config row.
+func (s *Store) LoadConfig() (storage.Config, error) {
+	config := storage.Config{}
+	var encryptedAccounts string
+	err := s.db.QueryRow(`SELECT active_account, encrypted_accounts, storage FROM workspace_config WHERE id = 1`).
+		Scan(&config.ActiveAccount, &encryptedAccounts, &config.Storage)
+	if err == sql.ErrNoRows {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+	if encryptedAccounts != "" {
+		config.EncryptedAccounts = strings.Split(encryptedAccounts, ",")
+	}
+	return config, nil
+}
+
+// SaveConfig upserts the single workspace_config row.
+func (s *Store) SaveConfig(config storage.Config) error {
+	_, err := s.db.Exec(`
+		INSERT INTO workspace_config (id, active_account, encrypted_accounts, storage) VALUES (1, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			active_account = excluded.active_account,
+			encrypted_accounts = excluded.encrypted_accounts,
+			storage = excluded.storage`,
+		config.ActiveAccount, strings.Join(config.EncryptedAccounts, ","), config.Storage)
+	return err
+}
@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RichardMarks/my-little-ledger/internal/ledger"
+	"github.com/RichardMarks/my-little-ledger/internal/storage/jsonstore"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := New(filepath.Join(t.TempDir(), "ledger.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestSaveAndLoadAccountRoundTrip(t *testing.T) {
+	store := newTestStore(t)
+
+	account := ledger.CreateAccount(0)
+	account.Deposit(ledger.FToMoney(20))
+	account.Transactions[0].Memo = "paycheck"
+
+	if err := store.SaveAccount("checking", &account); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := store.LoadAccount("checking")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Balance != account.Balance {
+		t.Fatalf("expected balance %s, got %s", ledger.FormatMoney(account.Balance), ledger.FormatMoney(loaded.Balance))
+	}
+	if len(loaded.Transactions) != 1 || loaded.Transactions[0].Memo != "paycheck" {
+		t.Fatalf("unexpected transactions: %+v", loaded.Transactions)
+	}
+}
+
+func TestListTransactionsRangeFiltersAndPaginates(t *testing.T) {
+	store := newTestStore(t)
+
+	account := ledger.CreateAccount(0)
+	if err := store.SaveAccount("checking", &account); err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		transaction := ledger.Transaction{
+			Timestamp: base.AddDate(0, 0, i).Unix(),
+			Income:    ledger.FToMoney(10),
+			Balance:   ledger.FToMoney(10 * float64(i+1)),
+		}
+		if err := store.AppendTransaction("checking", transaction); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	inRange, err := store.ListTransactionsRange("checking", base.AddDate(0, 0, 1), base.AddDate(0, 0, 3), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inRange) != 3 {
+		t.Fatalf("expected 3 transactions within range, got %d: %+v", len(inRange), inRange)
+	}
+
+	page, err := store.ListTransactionsRange("checking", time.Time{}, time.Time{}, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 {
+		t.Fatalf("expected a page of 2, got %d: %+v", len(page), page)
+	}
+}
+
+// TestMigrateFromJSONStorePreservesAccount exercises the same
+// load-from-one-backend, save-to-another sequence `ledger migrate` uses.
+func TestMigrateFromJSONStorePreservesAccount(t *testing.T) {
+	src := jsonstore.New(t.TempDir())
+	dst := newTestStore(t)
+
+	account := ledger.CreateAccount(0)
+	account.Deposit(ledger.FToMoney(50))
+	account.Withdraw(ledger.FToMoney(10))
+	if err := src.SaveAccount("checking", &account); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := src.LoadAccount("checking")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.SaveAccount("checking", loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, err := dst.LoadAccount("checking")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated.Balance != account.Balance {
+		t.Fatalf("expected migrated balance %s, got %s", ledger.FormatMoney(account.Balance), ledger.FormatMoney(migrated.Balance))
+	}
+	if len(migrated.Transactions) != len(account.Transactions) {
+		t.Fatalf("expected %d transactions, got %d", len(account.Transactions), len(migrated.Transactions))
+	}
+}
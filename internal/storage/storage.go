@@ -0,0 +1,37 @@
+// Package storage defines the persistence interface the ledger workspace
+// is built against, so accounts and transactions can live in a plain
+// JSON file (see jsonstore) or a SQLite database (see sqlite) behind the
+// same API.
+package storage
+
+import (
+	"time"
+
+	"github.com/RichardMarks/my-little-ledger/internal/ledger"
+)
+
+// Config is the workspace-level configuration a backend persists,
+// independent of how it stores accounts and transactions themselves.
+type Config struct {
+	ActiveAccount     string   `json:"activeAccount"`
+	EncryptedAccounts []string `json:"encryptedAccounts,omitempty"`
+	Storage           string   `json:"storage,omitempty"`
+}
+
+// Storage is implemented by each persistence backend a workspace can use.
+type Storage interface {
+	LoadAccount(name string) (*ledger.Account, error)
+	SaveAccount(name string, account *ledger.Account) error
+	ListAccounts() ([]string, error)
+	AppendTransaction(name string, transaction ledger.Transaction) error
+	LoadConfig() (Config, error)
+	SaveConfig(config Config) error
+}
+
+// RangeLister is implemented by backends that can filter a single
+// account's transactions by date range at the storage layer, instead of
+// loading the whole account just to filter it in memory. sqlite.Store
+// implements this; jsonstore.Store does not.
+type RangeLister interface {
+	ListTransactionsRange(name string, from, to time.Time, limit, offset int) ([]ledger.Transaction, error)
+}
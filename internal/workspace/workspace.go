@@ -0,0 +1,238 @@
+// Package workspace manages the on-disk .ledger/ workspace: its config
+// file and the per-account directories beneath it.
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/RichardMarks/my-little-ledger/internal/ledger"
+	"github.com/RichardMarks/my-little-ledger/internal/storage"
+	"github.com/RichardMarks/my-little-ledger/internal/storage/jsonstore"
+	"github.com/RichardMarks/my-little-ledger/internal/storage/sqlite"
+)
+
+// Config represents the configuration for the ledger workspace
+type Config struct {
+	ActiveAccount     string   `json:"activeAccount"`
+	EncryptedAccounts []string `json:"encryptedAccounts,omitempty"`
+	Storage           string   `json:"storage,omitempty"`
+}
+
+// DefaultConfig builds the configuration written by `ledger init`
+func DefaultConfig() Config {
+	return Config{ActiveAccount: "default"}
+}
+
+func createPath(userPath string) error {
+	if _, err := os.Stat(userPath); os.IsNotExist(err) {
+		err = os.MkdirAll(userPath, os.ModePerm)
+		if err != nil {
+			fmt.Println(err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+func createFile(fileName string, fileBytes []byte) error {
+	err := ioutil.WriteFile(fileName, fileBytes, 0644)
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	return nil
+}
+
+// Path returns the absolute path to the .ledger/ workspace directory
+func Path() string {
+	absolutePathToCurrentDirectory, _ := filepath.Abs("./")
+	return path.Join(absolutePathToCurrentDirectory, ".ledger/")
+}
+
+// ConfigPath returns the absolute path to the workspace config.json
+func ConfigPath() string {
+	return path.Join(Path(), "config.json")
+}
+
+// AccountPath returns the absolute path to an account's directory
+func AccountPath(accountName string) string {
+	return path.Join(Path(), accountName)
+}
+
+// AccountDataPath returns the absolute path to an account's data.json
+func AccountDataPath(accountName string) string {
+	return path.Join(AccountPath(accountName), "data.json")
+}
+
+// Save writes the config as JSON to the given path
+func (config *Config) Save(fileName string) error {
+	configFileBytes, err := json.Marshal(config)
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	return createFile(fileName, configFileBytes)
+}
+
+// Load reads the config from a JSON file at the given path
+func (config *Config) Load(fileName string) error {
+	configFileBytes, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	err = json.Unmarshal(configFileBytes, &config)
+	if err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	return nil
+}
+
+// Initialize creates the .ledger/ workspace and its default account
+func Initialize() error {
+	workspacePath := Path()
+	createPath(workspacePath)
+
+	config := DefaultConfig()
+	config.Save(ConfigPath())
+
+	defaultAccountPath := AccountPath("default")
+	createPath(defaultAccountPath)
+
+	defaultAccount := ledger.CreateAccount(0)
+	defaultAccount.SaveToFile(AccountDataPath("default"))
+
+	fmt.Println("\n*** initialized ledger workspace")
+	return nil
+}
+
+// SelectActiveAccount records accountName as the workspace's active account
+func SelectActiveAccount(accountName string) {
+	config := Config{}
+	config.Load(ConfigPath())
+	config.ActiveAccount = accountName
+	config.Save(ConfigPath())
+}
+
+// ActiveAccount returns the name of the workspace's active account
+func ActiveAccount() string {
+	config := Config{}
+	config.Load(ConfigPath())
+	return config.ActiveAccount
+}
+
+// CreateNewAccount creates a new account through the workspace's
+// configured storage backend and selects it as active.
+func CreateNewAccount(accountName string) error {
+	store, err := OpenStorage()
+	if err != nil {
+		return err
+	}
+	account := ledger.CreateAccount(0)
+	if err := store.SaveAccount(accountName, &account); err != nil {
+		return err
+	}
+	SelectActiveAccount(accountName)
+	return nil
+}
+
+// CreateNewEncryptedAccount creates a new account directory, saving its
+// data.json encrypted under passphrase, and selects it as active.
+func CreateNewEncryptedAccount(accountName string, passphrase string) error {
+	accountPath := AccountPath(accountName)
+	createPath(accountPath)
+	account := ledger.CreateAccount(0)
+	if err := account.SaveEncrypted(AccountDataPath(accountName), passphrase); err != nil {
+		return err
+	}
+	MarkEncrypted(accountName)
+	SelectActiveAccount(accountName)
+	return nil
+}
+
+// MarkEncrypted records accountName as using an encrypted data.json so
+// later loads know to prompt for a passphrase.
+func MarkEncrypted(accountName string) {
+	config := Config{}
+	config.Load(ConfigPath())
+	for _, name := range config.EncryptedAccounts {
+		if name == accountName {
+			return
+		}
+	}
+	config.EncryptedAccounts = append(config.EncryptedAccounts, accountName)
+	config.Save(ConfigPath())
+}
+
+// IsEncrypted reports whether accountName was created with --encrypted.
+func IsEncrypted(accountName string) bool {
+	config := Config{}
+	config.Load(ConfigPath())
+	for _, name := range config.EncryptedAccounts {
+		if name == accountName {
+			return true
+		}
+	}
+	return false
+}
+
+// StorageBackend returns the workspace's configured storage backend
+// ("json" or "sqlite"), defaulting to "json" when unset.
+func StorageBackend() string {
+	config := Config{}
+	config.Load(ConfigPath())
+	if config.Storage == "" {
+		return "json"
+	}
+	return config.Storage
+}
+
+// SetStorageBackend records name as the workspace's storage backend.
+func SetStorageBackend(name string) {
+	config := Config{}
+	config.Load(ConfigPath())
+	config.Storage = name
+	config.Save(ConfigPath())
+}
+
+// OpenStorageBackend opens a storage backend by name ("json" or
+// "sqlite"), regardless of which one is currently configured. `ledger
+// migrate` uses this to read from one backend while writing to another.
+func OpenStorageBackend(name string) (storage.Storage, error) {
+	switch name {
+	case "json":
+		return jsonstore.New(Path()), nil
+	case "sqlite":
+		return sqlite.New(path.Join(Path(), "ledger.db"))
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", name)
+	}
+}
+
+// OpenStorage opens the workspace's currently configured storage backend.
+func OpenStorage() (storage.Storage, error) {
+	return OpenStorageBackend(StorageBackend())
+}
+
+// ListAccounts returns the name of every account directory in the
+// workspace. Accounts held by the sqlite backend have no directory of
+// their own and are not listed.
+func ListAccounts() ([]string, error) {
+	entries, err := ioutil.ReadDir(Path())
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			accounts = append(accounts, entry.Name())
+		}
+	}
+	return accounts, nil
+}
@@ -1,229 +1,395 @@
 package main
 
 import (
-	"encoding/json"
+	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
-	"path"
-	"path/filepath"
 	"strings"
-	"time"
-)
 
-// Money represents dollars and cents as an integer value
-type Money int64
+	"golang.org/x/term"
 
-// Transaction represents a single transaction in an Account
-type Transaction struct {
-	Timestamp int64 `json:"timestamp"`
-	Income    Money `json:"income"`
-	Expense   Money `json:"expense"`
-	Balance   Money `json:"balance"`
-}
+	"github.com/RichardMarks/my-little-ledger/internal/importexport"
+	"github.com/RichardMarks/my-little-ledger/internal/ledger"
+	"github.com/RichardMarks/my-little-ledger/internal/shell"
+	"github.com/RichardMarks/my-little-ledger/internal/workspace"
+	"github.com/RichardMarks/my-little-ledger/pkg/server"
+)
 
-// Account represents a single ledger account
-type Account struct {
-	Balance      Money         `json:"balance"`
-	StartBalance Money         `json:"startBalance"`
-	Transactions []Transaction `json:"transactions"`
+func getCommandLine() (int, []string) {
+	argc := len(os.Args) - 1
+	argv := os.Args[1:]
+	return argc, argv
 }
 
-func fToMoney(f float64) Money {
-	return Money(f * 100)
+func showHelp() {
+	fmt.Println("Usage:")
+	fmt.Println("")
+	fmt.Println("")
+	fmt.Println("")
+	os.Exit(0)
 }
 
-func moneyToF(money Money) float64 {
-	return float64(money) * 0.01
+func unknownAction(action string) {
+	fmt.Printf("Unknown Action %s\n", action)
+	os.Exit(-1)
 }
 
-func printMoney(money Money) {
-	fmt.Printf("$%10.2f", moneyToF(money))
-}
+func listTransactions() {
+	account, err := loadActiveAccount()
+	if err != nil {
+		os.Exit(1)
+	}
 
-func formatMoney(money Money) string {
-	return fmt.Sprintf("$%10.2f", moneyToF(money))
-}
+	fmt.Printf("Starting Balance:\t%s\n", ledger.FormatMoney(account.StartBalance))
+	fmt.Printf("Balance:\t\t%s\n", ledger.FormatMoney(account.Balance))
+	hr := strings.Repeat("-", 90)
 
-func createAccount(startBalance float64) Account {
-	balance := fToMoney(startBalance)
-	account := Account{Balance: balance, StartBalance: balance}
-	account.Transactions = make([]Transaction, 0)
-	return account
+	fmt.Println("Transactions: ")
+	for i, transaction := range account.Transactions {
+		fmt.Printf("%04d: %s IN %s OUT %s BAL - %s\n%s\n", i,
+			ledger.FormatMoney(transaction.Income), ledger.FormatMoney(transaction.Expense),
+			ledger.FormatMoney(transaction.Balance), ledger.FormatTimestamp(transaction.Timestamp), hr)
+	}
 }
 
-func (account *Account) makeTransaction(income Money, expense Money) Money {
-	newBalance := income + account.Balance - expense
-	account.Balance = newBalance
-	timestamp := time.Now().Unix()
-	transaction := Transaction{Balance: newBalance, Income: income, Expense: expense, Timestamp: timestamp}
-	account.Transactions = append(account.Transactions, transaction)
-	return newBalance
+func startInteractiveLedger() {
+	s, err := shell.New(os.Stdout)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	if err := s.Run(os.Stdin); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
 }
 
-func (account *Account) deposit(amount Money) Money {
-	fmt.Printf("Depositing\t\t%s\n", formatMoney(amount))
-	return account.makeTransaction(amount, 0.0)
+func promptPassphrase(prompt string) (string, error) {
+	fmt.Print(prompt)
+	passphraseBytes, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	return string(passphraseBytes), nil
 }
 
-func (account *Account) withdraw(amount Money) Money {
-	fmt.Printf("Withdrawing\t\t%s\n", formatMoney(amount))
-	return account.makeTransaction(0.0, amount)
-}
+func createNewAccountCommand(args []string) {
+	if len(args) < 1 {
+		fmt.Println("Unable to create account. Missing Required Account Name")
+		os.Exit(-1)
+	}
+	accountName := strings.ToLower(strings.Trim(args[0], " \n"))
+	encrypted := len(args) >= 2 && strings.ToLower(strings.Trim(args[1], " \n")) == "--encrypted"
+
+	if !encrypted {
+		if err := workspace.CreateNewAccount(accountName); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		return
+	}
 
-func (account *Account) saveToFile(name string) error {
-	accountFileBytes, err := json.Marshal(account)
+	passphrase, err := promptPassphrase("Passphrase: ")
 	if err != nil {
 		fmt.Println(err.Error())
-		return err
+		os.Exit(1)
 	}
-	err = ioutil.WriteFile(name, accountFileBytes, 0644)
+	confirm, err := promptPassphrase("Confirm passphrase: ")
 	if err != nil {
 		fmt.Println(err.Error())
-		return err
+		os.Exit(1)
+	}
+	if passphrase != confirm {
+		fmt.Println("Passphrases do not match")
+		os.Exit(1)
+	}
+
+	if err := workspace.CreateNewEncryptedAccount(accountName, passphrase); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
 	}
-	return nil
 }
 
-func (account *Account) readFromFile(name string) error {
-	accountFileBytes, err := ioutil.ReadFile(name)
+// unlockAccount decrypts the active account with a prompted passphrase and
+// drops into the interactive shell, which keeps the passphrase cached in
+// memory so later mutations don't reprompt.
+func unlockAccount() {
+	name := workspace.ActiveAccount()
+	if !workspace.IsEncrypted(name) {
+		fmt.Printf("Account %q is not encrypted\n", name)
+		return
+	}
+
+	passphrase, err := promptPassphrase("Passphrase: ")
 	if err != nil {
 		fmt.Println(err.Error())
-		return err
+		os.Exit(1)
 	}
-	err = json.Unmarshal(accountFileBytes, &account)
+
+	s, err := shell.NewWithPassphrase(os.Stdout, passphrase)
 	if err != nil {
 		fmt.Println(err.Error())
-		return err
+		os.Exit(1)
+	}
+	if err := s.Run(os.Stdin); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
 	}
-	return nil
 }
 
-func formatTimestamp(timestamp int64) string {
-	// MM/DD/YYYY HH:MM:SS PM TZ
-	return time.Unix(timestamp, 0).Format("01/02/2006 03:04:05 PM MST")
-}
+func serveDaemon(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	bindAddress := flags.String("bind", "", "gRPC bind address, e.g. 127.0.0.1:9090")
+	gatewayAddr := flags.String("http", "", "HTTP/JSON gateway bind address; unset disables the gateway")
+	tlsCertFile := flags.String("tls-cert", "", "TLS certificate file")
+	tlsKeyFile := flags.String("tls-key", "", "TLS key file")
+	flags.Parse(args)
 
-func getCommandLine() (int, []string) {
-	argc := len(os.Args) - 1
-	argv := os.Args[1:]
-	return argc, argv
-}
+	config, err := server.LoadConfig(server.ConfigPath())
+	if err != nil {
+		config = server.DefaultConfig()
+	}
 
-func showHelp() {
-	fmt.Println("Usage:")
-	fmt.Println("")
-	fmt.Println("")
-	fmt.Println("")
-	os.Exit(0)
-}
+	opts := server.OptionsFromConfig(server.Options{
+		BindAddress: *bindAddress,
+		GatewayAddr: *gatewayAddr,
+		TLSCertFile: *tlsCertFile,
+		TLSKeyFile:  *tlsKeyFile,
+	}, config)
 
-// WorkspaceConfig represents the configuration for the ledger workspace
-type WorkspaceConfig struct {
-	ActiveAccount string `json:"activeAccount"`
+	if err := server.Serve(opts); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
 }
 
-func createPath(userPath string) error {
-	if _, err := os.Stat(userPath); os.IsNotExist(err) {
-		err = os.MkdirAll(userPath, os.ModePerm)
-		if err != nil {
-			fmt.Println(err.Error())
-			return err
-		}
+// migrateCommand copies every account and the workspace config from one
+// storage backend to another, then switches the workspace over to the
+// destination backend. Encrypted accounts are skipped: their data.json is
+// an opaque ciphertext envelope the backends can't decrypt without a
+// passphrase, so copying it as-is would overwrite it with a blank,
+// unencrypted account instead of migrating it.
+func migrateCommand(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	from := flags.String("from", "json", "source storage backend (json|sqlite)")
+	to := flags.String("to", "sqlite", "destination storage backend (json|sqlite)")
+	flags.Parse(args)
+
+	src, err := workspace.OpenStorageBackend(*from)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	dst, err := workspace.OpenStorageBackend(*to)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
 	}
-	return nil
-}
 
-func createFile(fileName string, fileBytes []byte) error {
-	err := ioutil.WriteFile(fileName, fileBytes, 0644)
+	accounts, err := src.ListAccounts()
 	if err != nil {
 		fmt.Println(err.Error())
-		return err
+		os.Exit(1)
+	}
+	migrated := 0
+	for _, name := range accounts {
+		if workspace.IsEncrypted(name) {
+			fmt.Printf("skipping %q: encrypted accounts must be migrated manually (unlock, then re-save under the new backend)\n", name)
+			continue
+		}
+		account, err := src.LoadAccount(name)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		if err := dst.SaveAccount(name, account); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		migrated++
 	}
-	return nil
-}
 
-func getWorkspacePath() string {
-	absolutePathToCurrentDirectory, _ := filepath.Abs("./")
-	workspacePath := path.Join(absolutePathToCurrentDirectory, ".ledger/")
-	return workspacePath
-}
+	if config, err := src.LoadConfig(); err == nil {
+		config.Storage = *to
+		dst.SaveConfig(config)
+	}
+	workspace.SetStorageBackend(*to)
 
-func getWorkspaceConfigPath() string {
-	workspacePath := getWorkspacePath()
-	configFilePath := path.Join(workspacePath, "config.json")
-	return configFilePath
+	fmt.Printf("migrated %d account(s) from %s to %s\n", migrated, *from, *to)
 }
 
-func createDefaultWorkspaceConfiguration() WorkspaceConfig {
-	config := WorkspaceConfig{ActiveAccount: "default"}
-	return config
+func loadActiveAccount() (ledger.Account, error) {
+	store, err := workspace.OpenStorage()
+	if err != nil {
+		return ledger.Account{}, err
+	}
+	account, err := store.LoadAccount(workspace.ActiveAccount())
+	if err != nil {
+		return ledger.Account{}, err
+	}
+	return *account, nil
 }
 
-func (config *WorkspaceConfig) save(fileName string) error {
-	configFileBytes, err := json.Marshal(config)
+func saveActiveAccount(account *ledger.Account) error {
+	store, err := workspace.OpenStorage()
 	if err != nil {
-		fmt.Println(err.Error())
 		return err
 	}
-	err = createFile(fileName, configFileBytes)
-	return err
+	return store.SaveAccount(workspace.ActiveAccount(), account)
 }
 
-func (config *WorkspaceConfig) load(fileName string) error {
-	configFileBytes, err := ioutil.ReadFile(fileName)
+func importCommand(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "csv", "import format (csv|ofx)")
+	mappingPath := flags.String("mapping", "", "CSV column mapping file (see importexport.CSVMapping)")
+	flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		fmt.Println("Usage: ledger import --format=csv|ofx <file>")
+		os.Exit(-1)
+	}
+	file, err := os.Open(flags.Arg(0))
 	if err != nil {
 		fmt.Println(err.Error())
-		return err
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	var imported []ledger.Transaction
+	switch *format {
+	case "csv":
+		mapping := importexport.DefaultCSVMapping()
+		if *mappingPath != "" {
+			mapping, err = importexport.LoadCSVMapping(*mappingPath)
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		}
+		imported, err = importexport.ImportCSV(file, mapping)
+	case "ofx":
+		imported, err = importexport.ImportOFX(file)
+	default:
+		err = fmt.Errorf("unknown import format %q", *format)
 	}
-	err = json.Unmarshal(configFileBytes, &config)
 	if err != nil {
 		fmt.Println(err.Error())
-		return err
+		os.Exit(1)
 	}
-	return nil
-}
 
-func initializeWorkspace() error {
-	workspacePath := getWorkspacePath()
-	createPath(workspacePath)
+	account, err := loadActiveAccount()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	deduped := importexport.DedupeByFITID(account.Transactions, imported)
+	account.Transactions = append(account.Transactions, deduped...)
+	account.RecalculateBalances()
+	if err := saveActiveAccount(&account); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
 
-	configFilePath := path.Join(workspacePath, "config.json")
-	config := createDefaultWorkspaceConfiguration()
-	config.save(configFilePath)
+	fmt.Printf("imported %d transaction(s), skipped %d already-imported duplicate(s)\n", len(deduped), len(imported)-len(deduped))
+}
 
-	defaultAccountPath := path.Join(workspacePath, "default")
-	createPath(defaultAccountPath)
+func exportCommand(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "json", "export format (csv|ofx|json)")
+	flags.Parse(args)
 
-	defaultAccount := createAccount(0)
-	defaultAccountFilePath := path.Join(defaultAccountPath, "data.json")
-	defaultAccount.saveToFile(defaultAccountFilePath)
+	if flags.NArg() < 1 {
+		fmt.Println("Usage: ledger export --format=csv|ofx|json <file>")
+		os.Exit(-1)
+	}
+	file, err := os.Create(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer file.Close()
 
-	fmt.Println("\n*** initialized ledger workspace")
-	return nil
-}
+	account, err := loadActiveAccount()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
 
-func selectActiveAccount(accountName string) {
-	configFilePath := getWorkspaceConfigPath()
-	config := WorkspaceConfig{}
-	config.load(configFilePath)
-	config.ActiveAccount = accountName
-	config.save(configFilePath)
+	switch *format {
+	case "csv":
+		err = importexport.ExportCSV(file, &account)
+	case "ofx":
+		err = importexport.ExportOFX(file, &account)
+	case "json":
+		err = importexport.ExportJSON(file, &account)
+	default:
+		err = fmt.Errorf("unknown export format %q", *format)
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
 }
 
-func createNewAccount(accountName string) {
-	workspacePath := getWorkspacePath()
-	accountPath := path.Join(workspacePath, accountName)
-	createPath(accountPath)
-	account := createAccount(0)
-	accountFilePath := path.Join(accountPath, "data.json")
-	account.saveToFile(accountFilePath)
-	selectActiveAccount(accountName)
-}
+// reconcileCommand reports transactions present in a CSV or OFX statement
+// but missing from the active account, and vice versa, matching by FITID
+// when both sides have one and by amount+date otherwise.
+func reconcileCommand(args []string) {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	format := flags.String("format", "ofx", "statement format (csv|ofx)")
+	mappingPath := flags.String("mapping", "", "CSV column mapping file (see importexport.CSVMapping)")
+	flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		fmt.Println("Usage: ledger reconcile --format=csv|ofx <file>")
+		os.Exit(-1)
+	}
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	var statementTransactions []ledger.Transaction
+	switch *format {
+	case "csv":
+		mapping := importexport.DefaultCSVMapping()
+		if *mappingPath != "" {
+			mapping, err = importexport.LoadCSVMapping(*mappingPath)
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		}
+		statementTransactions, err = importexport.ImportCSV(file, mapping)
+	case "ofx":
+		statementTransactions, err = importexport.ImportOFX(file)
+	default:
+		err = fmt.Errorf("unknown statement format %q", *format)
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
 
-func unknownAction(action string) {
-	fmt.Printf("Unknown Action %s\n", action)
-	os.Exit(-1)
+	account, err := loadActiveAccount()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	report := importexport.Reconcile(importexport.ToRecords(account.Transactions), importexport.ToRecords(statementTransactions))
+
+	fmt.Printf("Missing from ledger (%d):\n", len(report.MissingFromLedger))
+	for _, record := range report.MissingFromLedger {
+		fmt.Printf("  %s %s %s\n", record.Date.Format("2006-01-02"), ledger.FormatMoney(record.Amount), record.Memo)
+	}
+	fmt.Printf("Missing from statement (%d):\n", len(report.MissingFromFile))
+	for _, record := range report.MissingFromFile {
+		fmt.Printf("  %s %s %s\n", record.Date.Format("2006-01-02"), ledger.FormatMoney(record.Amount), record.Memo)
+	}
 }
 
 func main() {
@@ -234,17 +400,25 @@ func main() {
 		action := strings.ToLower(strings.Trim(argv[0], " \n"))
 		switch action {
 		case "init":
-			initializeWorkspace()
+			workspace.Initialize()
 		case "new":
-			if argc >= 2 {
-				accountName := strings.ToLower(strings.Trim(argv[1], " \n"))
-				createNewAccount(accountName)
-			} else {
-				fmt.Println("Unable to create account. Missing Required Account Name")
-				os.Exit(-1)
-			}
-		// case "ls": listTransactions()
-		// case "shell": startInteractiveLedger()
+			createNewAccountCommand(argv[1:])
+		case "ls":
+			listTransactions()
+		case "shell":
+			startInteractiveLedger()
+		case "serve":
+			serveDaemon(argv[1:])
+		case "unlock":
+			unlockAccount()
+		case "migrate":
+			migrateCommand(argv[1:])
+		case "import":
+			importCommand(argv[1:])
+		case "export":
+			exportCommand(argv[1:])
+		case "reconcile":
+			reconcileCommand(argv[1:])
 		default:
 			unknownAction(action)
 		}
@@ -264,53 +438,18 @@ func main() {
 	//    list transactions in active account
 	// $ appname shell
 	//    start the interactive shell to create, update, and delete transactions
-
-	// createAccountFlag := flag.Bool("createaccount", false, "specifies to create an account")
-	// argv := flag.Args()
-	// fmt.Println(*createAccountFlag)
-
-	fmt.Print(argc, argv)
-
-	// account := createAccount(0)
-
-	// err := account.readFromFile("my-account.json")
-	// if err != nil {
-	// 	fmt.Println("There was a critical error reading the account database!")
-	// 	fmt.Println(err.Error())
-	// 	os.Exit(1)
-	// }
-
-	// // accountFileBytes := []byte(`{ "startBalance": 0.00, "balance": 0.00, "transactions": [] }`)
-
-	// fmt.Printf("Starting Balance:\t%s\n", formatMoney(account.StartBalance))
-
-	// // account.deposit(fToMoney(100.0))
-	// // account.withdraw(fToMoney(30))
-
-	// fmt.Printf("Balance:\t\t%s\n", formatMoney(account.Balance))
-	// hr := strings.Repeat("-", 90)
-
-	// fmt.Println("Transactions: ")
-	// for i := 0; i < len(account.Transactions); i++ {
-	// 	transaction := account.Transactions[i]
-	// 	income := transaction.Income
-	// 	expense := transaction.Expense
-	// 	balance := transaction.Balance
-	// 	ts := transaction.Timestamp
-
-	// 	fmt.Printf("%04d: %s IN %s OUT %s BAL - %s\n%s\n", i, formatMoney(income), formatMoney(expense), formatMoney(balance), formatTimestamp(ts), hr)
-	// }
-
-	// // accountFileBytes, err := json.Marshal(account)
-	// // if err != nil {
-	// // 	log.Print(err)
-	// // 	return
-	// // }
-
-	// // err = ioutil.WriteFile("account.json", accountFileBytes, 0644)
-	// // if err != nil {
-	// // 	log.Print(err)
-	// // 	return
-	// // }
-
+	// $ appname serve [--bind=host:port] [--http=host:port] [--tls-cert=...] [--tls-key=...]
+	//    start the gRPC (and optional HTTP/JSON gateway) daemon
+	// $ appname new accountname --encrypted
+	//    create .ledger/accountname/data.json encrypted with a prompted passphrase
+	// $ appname unlock
+	//    decrypt the active (encrypted) account and start the interactive shell
+	// $ appname migrate --from=json --to=sqlite
+	//    copy every account and the workspace config to another storage backend
+	// $ appname import --format=csv|ofx [--mapping=columns.json] <file>
+	//    append transactions from a CSV or OFX statement to the active account
+	// $ appname export --format=csv|ofx|json <file>
+	//    write the active account's transactions to a CSV, OFX, or JSON file
+	// $ appname reconcile --format=csv|ofx [--mapping=columns.json] <file>
+	//    compare a CSV or OFX statement against the active account's transactions
 }
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"path"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/RichardMarks/my-little-ledger/internal/workspace"
+)
+
+// Config controls how `ledger serve` binds and, optionally, terminates TLS.
+// It is discovered from .ledger/config.toml and may be overridden by CLI
+// flags.
+type Config struct {
+	BindAddress string `toml:"bind_address"`
+	TLSCertFile string `toml:"tls_cert_file"`
+	TLSKeyFile  string `toml:"tls_key_file"`
+}
+
+// DefaultConfig is used when no .ledger/config.toml is present.
+func DefaultConfig() Config {
+	return Config{BindAddress: "127.0.0.1:9090"}
+}
+
+// ConfigPath returns the path `ledger serve` looks for its TOML config at.
+func ConfigPath() string {
+	return path.Join(workspace.Path(), "config.toml")
+}
+
+// LoadConfig reads the TOML config at path, falling back to DefaultConfig
+// for any field left unset in the file.
+func LoadConfig(path string) (Config, error) {
+	config := DefaultConfig()
+	if _, err := toml.DecodeFile(path, &config); err != nil {
+		return config, err
+	}
+	return config, nil
+}
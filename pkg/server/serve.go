@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	gw "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+
+	"github.com/RichardMarks/my-little-ledger/pkg/ledgerpb"
+)
+
+// Options configures a single `ledger serve` run. Flags take precedence
+// over whatever Config was discovered on disk.
+type Options struct {
+	BindAddress string
+	GatewayAddr string
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
+// OptionsFromConfig fills in any Options field left at its zero value from
+// the on-disk Config.
+func OptionsFromConfig(opts Options, config Config) Options {
+	if opts.BindAddress == "" {
+		opts.BindAddress = config.BindAddress
+	}
+	if opts.TLSCertFile == "" {
+		opts.TLSCertFile = config.TLSCertFile
+	}
+	if opts.TLSKeyFile == "" {
+		opts.TLSKeyFile = config.TLSKeyFile
+	}
+	return opts
+}
+
+// Serve starts the gRPC server (and, if GatewayAddr is set, its JSON/HTTP
+// mirror) and blocks until the listener errors out.
+func Serve(opts Options) error {
+	lis, err := net.Listen("tcp", opts.BindAddress)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", opts.BindAddress, err)
+	}
+
+	var grpcOpts []grpc.ServerOption
+	if opts.TLSCertFile != "" && opts.TLSKeyFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(opts.TLSCertFile, opts.TLSKeyFile)
+		if err != nil {
+			return fmt.Errorf("load TLS credentials: %w", err)
+		}
+		grpcOpts = append(grpcOpts, grpc.Creds(creds))
+	}
+
+	ledgerServer, err := New()
+	if err != nil {
+		return fmt.Errorf("open storage backend: %w", err)
+	}
+
+	grpcServer := grpc.NewServer(grpcOpts...)
+	ledgerpb.RegisterLedgerServiceServer(grpcServer, ledgerServer)
+
+	errCh := make(chan error, 2)
+	go func() {
+		fmt.Fprintf(os.Stdout, "ledger serve: gRPC listening on %s\n", opts.BindAddress)
+		errCh <- grpcServer.Serve(lis)
+	}()
+
+	if opts.GatewayAddr != "" {
+		go func() {
+			errCh <- serveGateway(opts)
+		}()
+	}
+
+	return <-errCh
+}
+
+// gatewayDialOptions builds the DialOptions the gateway uses to connect
+// back to the gRPC server it's mirroring. When the server is terminating
+// TLS, the gateway trusts that same certificate; otherwise it dials
+// insecurely, since both ends are the same `ledger serve` process.
+func gatewayDialOptions(opts Options) ([]grpc.DialOption, error) {
+	if opts.TLSCertFile == "" || opts.TLSKeyFile == "" {
+		return []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, nil
+	}
+	creds, err := credentials.NewClientTLSFromFile(opts.TLSCertFile, "")
+	if err != nil {
+		return nil, fmt.Errorf("load TLS credentials: %w", err)
+	}
+	return []grpc.DialOption{grpc.WithTransportCredentials(creds)}, nil
+}
+
+func serveGateway(opts Options) error {
+	ctx := context.Background()
+	mux := gw.NewServeMux()
+
+	dialOpts, err := gatewayDialOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	err = ledgerpb.RegisterLedgerServiceHandlerFromEndpoint(ctx, mux, opts.BindAddress, dialOpts)
+	if err != nil {
+		return fmt.Errorf("register gateway: %w", err)
+	}
+
+	fmt.Fprintf(os.Stdout, "ledger serve: HTTP gateway listening on %s\n", opts.GatewayAddr)
+	return http.ListenAndServe(opts.GatewayAddr, mux)
+}
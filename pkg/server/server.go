@@ -0,0 +1,192 @@
+// Package server implements the gRPC LedgerService backing `ledger serve`,
+// reusing the same Account/Transaction model and on-disk workspace as the
+// CLI and shell, guarded by a mutex so concurrent RPCs can't race on the
+// workspace's storage backend.
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/RichardMarks/my-little-ledger/internal/ledger"
+	"github.com/RichardMarks/my-little-ledger/internal/storage"
+	"github.com/RichardMarks/my-little-ledger/internal/workspace"
+	"github.com/RichardMarks/my-little-ledger/pkg/ledgerpb"
+)
+
+// LedgerServer implements ledgerpb.LedgerServiceServer against the
+// workspace's configured storage backend.
+type LedgerServer struct {
+	ledgerpb.UnimplementedLedgerServiceServer
+
+	store storage.Storage
+	mu    sync.Mutex
+}
+
+// New builds a LedgerServer operating on the workspace's currently
+// configured storage backend.
+func New() (*LedgerServer, error) {
+	store, err := workspace.OpenStorage()
+	if err != nil {
+		return nil, err
+	}
+	return &LedgerServer{store: store}, nil
+}
+
+func toProtoAccount(name string, account *ledger.Account) *ledgerpb.Account {
+	transactions := make([]*ledgerpb.Transaction, len(account.Transactions))
+	for i, t := range account.Transactions {
+		transactions[i] = &ledgerpb.Transaction{
+			Timestamp:    t.Timestamp,
+			IncomeCents:  int64(t.Income),
+			ExpenseCents: int64(t.Expense),
+			BalanceCents: int64(t.Balance),
+		}
+	}
+	return &ledgerpb.Account{
+		Name:              name,
+		BalanceCents:      int64(account.Balance),
+		StartBalanceCents: int64(account.StartBalance),
+		Transactions:      transactions,
+	}
+}
+
+func (s *LedgerServer) loadAccount(name string) (ledger.Account, error) {
+	account, err := s.store.LoadAccount(name)
+	if err != nil {
+		return ledger.Account{}, fmt.Errorf("account %q: %w", name, err)
+	}
+	return *account, nil
+}
+
+// CreateAccount creates a new, empty account in the workspace and selects
+// it as active, mirroring `ledger new`.
+func (s *LedgerServer) CreateAccount(ctx context.Context, req *ledgerpb.CreateAccountRequest) (*ledgerpb.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account := ledger.CreateAccount(0)
+	if err := s.store.SaveAccount(req.Name, &account); err != nil {
+		return nil, err
+	}
+	workspace.SelectActiveAccount(req.Name)
+	return toProtoAccount(req.Name, &account), nil
+}
+
+// GetAccount returns the current state of a single account.
+func (s *LedgerServer) GetAccount(ctx context.Context, req *ledgerpb.GetAccountRequest) (*ledgerpb.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, err := s.loadAccount(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	return toProtoAccount(req.Name, &account), nil
+}
+
+// ListTransactions returns the transactions recorded against an account.
+// On backends that implement storage.RangeLister, a positive PageSize
+// limits the response to that many of the newest transactions, newest
+// first; otherwise every transaction is returned, oldest first. PageToken
+// is not read and NextPageToken is never set.
+func (s *LedgerServer) ListTransactions(ctx context.Context, req *ledgerpb.ListTransactionsRequest) (*ledgerpb.ListTransactionsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// On backends that can filter at the storage layer (sqlite), use the
+	// page size directly instead of loading every transaction just to
+	// hand back the first page of them.
+	if lister, ok := s.store.(storage.RangeLister); ok && req.PageSize > 0 {
+		transactions, err := lister.ListTransactionsRange(req.Name, time.Time{}, time.Time{}, int(req.PageSize), 0)
+		if err != nil {
+			return nil, fmt.Errorf("account %q: %w", req.Name, err)
+		}
+		return &ledgerpb.ListTransactionsResponse{
+			Transactions: toProtoAccount(req.Name, &ledger.Account{Transactions: transactions}).Transactions,
+		}, nil
+	}
+
+	account, err := s.loadAccount(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &ledgerpb.ListTransactionsResponse{
+		Transactions: toProtoAccount(req.Name, &account).Transactions,
+	}, nil
+}
+
+// Deposit records an incoming transaction on an account.
+func (s *LedgerServer) Deposit(ctx context.Context, req *ledgerpb.DepositRequest) (*ledgerpb.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, err := s.loadAccount(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	account.Deposit(ledger.Money(req.AmountCents))
+	if err := s.store.SaveAccount(req.Name, &account); err != nil {
+		return nil, err
+	}
+	return toProtoAccount(req.Name, &account), nil
+}
+
+// Withdraw records an outgoing transaction on an account.
+func (s *LedgerServer) Withdraw(ctx context.Context, req *ledgerpb.WithdrawRequest) (*ledgerpb.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, err := s.loadAccount(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	account.Withdraw(ledger.Money(req.AmountCents))
+	if err := s.store.SaveAccount(req.Name, &account); err != nil {
+		return nil, err
+	}
+	return toProtoAccount(req.Name, &account), nil
+}
+
+// Transfer atomically records a paired debit on FromAccount and a credit
+// on ToAccount. The server-wide mutex makes this appear atomic to other
+// RPCs; if the credit fails to save after the debit was already written,
+// the debit is rolled back rather than left stranded.
+func (s *LedgerServer) Transfer(ctx context.Context, req *ledgerpb.TransferRequest) (*ledgerpb.TransferResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.FromAccount == req.ToAccount {
+		return nil, fmt.Errorf("cannot transfer to the same account")
+	}
+
+	from, err := s.loadAccount(req.FromAccount)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.loadAccount(req.ToAccount)
+	if err != nil {
+		return nil, err
+	}
+	fromBefore := from
+
+	from.Withdraw(ledger.Money(req.AmountCents))
+	to.Deposit(ledger.Money(req.AmountCents))
+
+	if err := s.store.SaveAccount(req.FromAccount, &from); err != nil {
+		return nil, err
+	}
+	if err := s.store.SaveAccount(req.ToAccount, &to); err != nil {
+		if rollbackErr := s.store.SaveAccount(req.FromAccount, &fromBefore); rollbackErr != nil {
+			return nil, fmt.Errorf("credit to %q failed (%w), and rollback of debit from %q also failed: %v", req.ToAccount, err, req.FromAccount, rollbackErr)
+		}
+		return nil, fmt.Errorf("credit to %q failed, debit from %q rolled back: %w", req.ToAccount, req.FromAccount, err)
+	}
+
+	return &ledgerpb.TransferResponse{
+		FromAccount: toProtoAccount(req.FromAccount, &from),
+		ToAccount:   toProtoAccount(req.ToAccount, &to),
+	}, nil
+}